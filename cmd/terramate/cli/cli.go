@@ -11,6 +11,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -32,7 +33,6 @@ import (
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/fmt"
-	"github.com/terramate-io/terramate/hcl/info"
 	"github.com/terramate-io/terramate/modvendor/download"
 	"github.com/terramate-io/terramate/versions"
 
@@ -101,6 +101,7 @@ type cliSpec struct {
 	VersionFlag    bool     `name:"version" help:"Terramate version"`
 	Chdir          string   `short:"C" optional:"true" predictor:"file" help:"Sets working directory"`
 	GitChangeBase  string   `short:"B" optional:"true" help:"Git base ref for computing changes"`
+	GitChangeHead  string   `optional:"true" help:"Git head ref for computing changes (defaults to HEAD)"`
 	Changed        bool     `short:"c" optional:"true" help:"Filter by changed infrastructure"`
 	Tags           []string `optional:"true" sep:"none" help:"Filter stacks by tags. Use \":\" for logical AND and \",\" for logical OR. Example: --tags app:prod filters stacks containing tag \"app\" AND \"prod\". If multiple --tags are provided, an OR expression is created. Example: \"--tags a --tags b\" is the same as \"--tags a,b\""`
 	NoTags         []string `optional:"true" sep:"," help:"Filter stacks that do not have the given tags"`
@@ -115,6 +116,10 @@ type cliSpec struct {
 
 	DisableCheckpoint          bool `optional:"true" default:"false" help:"Disable checkpoint checks for updates"`
 	DisableCheckpointSignature bool `optional:"true" default:"false" help:"Disable checkpoint signature"`
+	EnableChangeCache          bool `optional:"true" default:"false" help:"Enable the cache of change detection results"`
+
+	Global      map[string]string `short:"g" optional:"true" help:"set/override globals. eg.: --global name=<expr>"`
+	GlobalsFile string            `optional:"true" predictor:"file" help:"File with globals blocks overriding the globals of the project"`
 
 	Create struct {
 		Path           string   `arg:"" optional:"" name:"path" predictor:"file" help:"Path of the new stack relative to the working dir"`
@@ -136,6 +141,8 @@ type cliSpec struct {
 
 	List struct {
 		Why                bool   `help:"Shows the reason why the stack has changed"`
+		All                bool   `help:"Also list the stacks disabled by their condition, with the reason"`
+		WhyTags            bool   `help:"Shows the tags of each stack and where they were declared"`
 		ExperimentalStatus string `help:"Filter by status"`
 	} `cmd:"" help:"List stacks"`
 
@@ -172,7 +179,10 @@ type cliSpec struct {
 
 		Metadata struct{} `cmd:"" help:"Shows metadata available on the project"`
 
-		Globals struct{} `cmd:"" help:"List globals for all stacks"`
+		Globals struct {
+			Trace  bool   `help:"Shows where each global was defined and which definitions it overrides"`
+			Format string `default:"text" enum:"text,json" help:"Output format of the --trace flag: 'text' or 'json'"`
+		} `cmd:"" help:"List globals for all stacks"`
 
 		Generate struct {
 			Debug struct{} `cmd:"" help:"Shows generate debug information"`
@@ -187,7 +197,17 @@ type cliSpec struct {
 			Basedir string `arg:"" optional:"true" help:"Base directory to search stacks"`
 		} `cmd:"" help:"Show the topological ordering of the stacks"`
 
-		RunEnv struct{} `cmd:"" help:"List run environment variables for all stacks"`
+		RunEnv struct {
+			Why bool `help:"Shows where each environment variable was defined"`
+		} `cmd:"" help:"List run environment variables for all stacks"`
+
+		Lint struct{} `cmd:"" help:"List the stacks violating the terramate.config.stacks policies"`
+
+		Affected struct {
+			Why    bool     `help:"Shows the reason why the stack is affected"`
+			AsJSON bool     `help:"Outputs the result as JSON"`
+			Paths  []string `arg:"" name:"path" predictor:"file" help:"Paths of the changed files or directories"`
+		} `cmd:"" help:"List stacks affected by changes in the given paths"`
 
 		Vendor struct {
 			Download struct {
@@ -195,23 +215,22 @@ type cliSpec struct {
 				Source    string `arg:"" name:"source" help:"Terraform module source URL, must be Git/Github and should not contain a reference"`
 				Reference string `arg:"" name:"ref" help:"Reference of the Terraform module to vendor"`
 			} `cmd:"" help:"Downloads a Terraform module and stores it on the project vendor dir"`
+
+			Imports struct{} `cmd:"" help:"Fetches the remote import sources of the project configuration"`
 		} `cmd:"" help:"Manages vendored Terraform modules"`
 
 		Eval struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			AsJSON bool              `help:"Outputs the result as a JSON value"`
-			Exprs  []string          `arg:"" help:"expressions to be evaluated" name:"expr" passthrough:""`
+			AsJSON bool     `help:"Outputs the result as a JSON value"`
+			Exprs  []string `arg:"" help:"expressions to be evaluated" name:"expr" passthrough:""`
 		} `cmd:"" help:"Eval expression"`
 
 		PartialEval struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			Exprs  []string          `arg:"" help:"expressions to be partially evaluated" name:"expr" passthrough:""`
+			Exprs []string `arg:"" help:"expressions to be partially evaluated" name:"expr" passthrough:""`
 		} `cmd:"" help:"Partial evaluate the expressions"`
 
 		GetConfigValue struct {
-			Global map[string]string `short:"g" help:"set/override globals. eg.: --global name=<expr>"`
-			AsJSON bool              `help:"Outputs the result as a JSON value"`
-			Vars   []string          `arg:"" help:"variable to be retrieved" name:"var" passthrough:""`
+			AsJSON bool     `help:"Outputs the result as a JSON value"`
+			Vars   []string `arg:"" help:"variable to be retrieved" name:"var" passthrough:""`
 		} `cmd:"" help:"Get configuration value"`
 
 		Cloud struct {
@@ -355,6 +374,10 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 		clicfg.DisableCheckpointSignature = parsedArgs.DisableCheckpointSignature
 	}
 
+	if parsedArgs.EnableChangeCache {
+		clicfg.EnableChangeCache = parsedArgs.EnableChangeCache
+	}
+
 	if clicfg.UserTerramateDir == "" {
 		homeTmDir, err := userTerramateDir()
 		if err != nil {
@@ -458,7 +481,11 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 
 	logger.Trace().Msg("Running in directory")
 
-	prj, foundRoot, err := lookupProject(wd)
+	// the remote imports are fetched while loading the configuration, which
+	// is the only time the project configuration accesses the network.
+	fetchImports := ctx.Command() == "experimental vendor imports"
+
+	prj, foundRoot, err := lookupProject(wd, fetchImports)
 	if err != nil {
 		fatal(err, "looking up project root")
 	}
@@ -474,6 +501,12 @@ func newCLI(version string, args []string, stdin io.Reader, stdout, stderr io.Wr
 		fatal(err, "setting configuration")
 	}
 
+	globalOverrides, err := loadGlobalOverrides(prj.rootdir, wd, &parsedArgs)
+	if err != nil {
+		fatal(err, "loading global overrides")
+	}
+	prj.root.SetGlobalOverrides(globalOverrides)
+
 	if parsedArgs.Changed && !prj.isRepo {
 		log.Fatal().Msg("flag --changed provided but no git repository found")
 	}
@@ -534,8 +567,10 @@ func (c *cli) run() {
 		log.Fatal().Msg("no command specified")
 	case "run <cmd>":
 		c.setupGit()
+		c.checkStacksPolicy()
 		c.runOnStacks()
 	case "generate":
+		c.checkStacksPolicy()
 		c.generate()
 	case "experimental clone <srcdir> <destdir>":
 		c.cloneStack()
@@ -545,6 +580,9 @@ func (c *cli) run() {
 		c.triggerStack(c.parsedArgs.Experimental.Trigger.Stack)
 	case "experimental vendor download <source> <ref>":
 		c.vendorDownload()
+	case "experimental vendor imports":
+		// nothing to do: the remote imports were fetched when the project
+		// configuration was loaded.
 	case "experimental globals":
 		c.setupGit()
 		c.printStacksGlobals()
@@ -563,6 +601,10 @@ func (c *cli) run() {
 	case "experimental run-env":
 		c.setupGit()
 		c.printRunEnv()
+	case "experimental lint":
+		c.lint()
+	case "experimental affected <path>":
+		c.printAffectedStacks()
 	case "experimental eval":
 		log.Fatal().Msg("no expression specified")
 	case "experimental eval <expr>":
@@ -602,6 +644,8 @@ func (c *cli) setupGit() {
 		} else {
 			c.prj.baseRef = c.prj.defaultBaseRef()
 		}
+
+		c.prj.headRef = c.parsedArgs.GitChangeHead
 	}
 }
 
@@ -738,7 +782,7 @@ func (c *cli) triggerStackByFilter() {
 		fatal(errors.E("trigger command expects either a stack path or the --experimental-status flag"))
 	}
 
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 	status := parseStatusFilter(c.parsedArgs.Experimental.Trigger.ExperimentalStatus)
 	stacksReport, err := c.listStacks(mgr, false, status)
 	if err != nil {
@@ -977,6 +1021,13 @@ func (c *cli) listStacks(mgr *stack.Manager, isChanged bool, status cloudstack.F
 			Msg("Listing changed stacks")
 
 		report, err = mgr.ListChanged()
+		if err == nil {
+			for _, dir := range report.Missing {
+				log.Warn().
+					Stringer("stack", dir).
+					Msg("ignoring stack changed in the head revision but missing in the working tree")
+			}
+		}
 	} else {
 		log.Trace().
 			Str("action", "listStacks()").
@@ -1091,6 +1142,7 @@ func (c *cli) initTerraform() {
 		fatal(err, "reloading the configuration")
 	}
 
+	root.SetGlobalOverrides(c.cfg().GlobalOverrides())
 	c.prj.root = *root
 
 	report, vendorReport := c.gencodeWithVendor()
@@ -1348,7 +1400,7 @@ func (c *cli) printStacks() {
 		log.Fatal().Msg("the --why flag must be used together with --changed")
 	}
 
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 
 	status := parseStatusFilter(c.parsedArgs.List.ExperimentalStatus)
 	report, err := c.listStacks(mgr, c.parsedArgs.Changed, status)
@@ -1358,7 +1410,17 @@ func (c *cli) printStacks() {
 
 	c.gitFileSafeguards(false)
 
-	for _, entry := range c.filterStacks(report.Stacks) {
+	entries := report.Stacks
+	disabled := map[prj.Path]bool{}
+	if c.parsedArgs.List.All {
+		entries = append(append([]stack.Entry{}, entries...), report.Disabled...)
+		sort.Sort(stack.EntrySlice(entries))
+		for _, entry := range report.Disabled {
+			disabled[entry.Stack.Dir] = true
+		}
+	}
+
+	for _, entry := range c.filterStacks(entries) {
 		stack := entry.Stack
 
 		log.Debug().Msgf("printing stack %s", stack.Dir)
@@ -1368,7 +1430,89 @@ func (c *cli) printStacks() {
 			continue
 		}
 
-		if c.parsedArgs.List.Why {
+		if disabled[stack.Dir] {
+			c.output.MsgStdOut("%s - %s", stackRepr, entry.Reason)
+		} else if c.parsedArgs.List.Why {
+			c.output.MsgStdOut("%s - %s", stackRepr, entry.Reason)
+		} else {
+			c.output.MsgStdOut(stackRepr)
+		}
+
+		if c.parsedArgs.List.WhyTags {
+			c.printStackTagsOrigin(stack)
+		}
+	}
+}
+
+func (c *cli) printStackTagsOrigin(st *config.Stack) {
+	tree, ok := c.cfg().Lookup(st.Dir)
+	if !ok || tree.Node.Stack == nil {
+		return
+	}
+	for _, tag := range tree.Node.Stack.Tags {
+		c.output.MsgStdOut("\t%s - declared by the stack", tag)
+	}
+	own := map[string]bool{}
+	for _, tag := range tree.Node.Stack.Tags {
+		own[tag] = true
+	}
+	for _, inherited := range tree.Node.Stack.InheritedTags {
+		if own[inherited.Name] {
+			continue
+		}
+		own[inherited.Name] = true
+		c.output.MsgStdOut("\t%s - inherited from the stack_defaults at %s",
+			inherited.Name, inherited.Origin)
+	}
+}
+
+func (c *cli) printAffectedStacks() {
+	paths, err := stack.NewAffectedPaths(c.rootdir(), c.wd(), c.parsedArgs.Experimental.Affected.Paths)
+	if err != nil {
+		fatal(err, "listing affected stacks")
+	}
+
+	mgr := c.stackManager()
+	report, err := mgr.ListAffected(paths)
+	if err != nil {
+		fatal(err, "listing affected stacks")
+	}
+
+	entries := c.filterStacks(report.Stacks)
+
+	if c.parsedArgs.Experimental.Affected.AsJSON {
+		type affectedStack struct {
+			Path   string `json:"path"`
+			Reason string `json:"reason"`
+		}
+
+		result := struct {
+			Stacks []affectedStack `json:"stacks"`
+		}{
+			Stacks: []affectedStack{},
+		}
+		for _, entry := range entries {
+			result.Stacks = append(result.Stacks, affectedStack{
+				Path:   entry.Stack.Dir.String(),
+				Reason: entry.Reason,
+			})
+		}
+
+		data, err := stdjson.MarshalIndent(result, "", "  ")
+		if err != nil {
+			fatal(err, "encoding affected stacks as JSON")
+		}
+		c.output.MsgStdOut(string(data))
+		return
+	}
+
+	for _, entry := range entries {
+		stackRepr, ok := c.friendlyFmtDir(entry.Stack.Dir.String())
+		if !ok {
+			continue
+		}
+
+		if c.parsedArgs.Experimental.Affected.Why {
 			c.output.MsgStdOut("%s - %s", stackRepr, entry.Reason)
 		} else {
 			c.output.MsgStdOut(stackRepr)
@@ -1388,14 +1532,14 @@ func parseStatusFilter(strStatus string) cloudstack.FilterStatus {
 }
 
 func (c *cli) printRunEnv() {
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 	report, err := c.listStacks(mgr, c.parsedArgs.Changed, cloudstack.NoFilter)
 	if err != nil {
 		fatal(err, "listing stacks")
 	}
 
 	for _, stackEntry := range c.filterStacks(report.Stacks) {
-		envVars, err := run.LoadEnv(c.cfg(), stackEntry.Stack)
+		envVars, err := run.LoadEnvVars(c.cfg(), stackEntry.Stack)
 		if err != nil {
 			fatal(err, "loading stack run environment")
 		}
@@ -1403,11 +1547,46 @@ func (c *cli) printRunEnv() {
 		c.output.MsgStdOut("\nstack %q:", stackEntry.Stack.Dir)
 
 		for _, envVar := range envVars {
-			c.output.MsgStdOut("\t%s", envVar)
+			if c.parsedArgs.Experimental.RunEnv.Why {
+				c.output.MsgStdOut("\t%s=%s - defined at %s",
+					envVar.Name, envVar.DisplayValue(), envVar.Origin)
+				continue
+			}
+			c.output.MsgStdOut("\t%s=%s", envVar.Name, envVar.DisplayValue())
 		}
 	}
 }
 
+func (c *cli) lint() {
+	tree, ok := c.cfg().Lookup(prj.PrjAbsPath(c.rootdir(), c.wd()))
+	if !ok {
+		return
+	}
+
+	err := tree.ValidateStacksPolicy()
+	if err == nil {
+		return
+	}
+
+	var violations *errors.List
+	if !errors.As(err, &violations) {
+		fatal(err, "checking the stacks policy")
+	}
+	for _, violation := range violations.Errors() {
+		c.output.MsgStdOut(violation.Error())
+	}
+	fatal(errors.E(config.ErrStackPolicy,
+		"found %d violations of the terramate.config.stacks policy", len(violations.Errors())))
+}
+
+// checkStacksPolicy aborts if any stack of the project violates the policy of
+// the terramate.config.stacks block.
+func (c *cli) checkStacksPolicy() {
+	if err := c.cfg().Tree().ValidateStacksPolicy(); err != nil {
+		fatal(err, "stacks violate the terramate.config.stacks policy")
+	}
+}
+
 func (c *cli) generateGraph() {
 	var getLabel func(s *config.Stack) string
 
@@ -1627,12 +1806,17 @@ func (c *cli) printStacksGlobals() {
 	logger.Trace().
 		Msg("Create new terramate manager.")
 
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 	report, err := c.listStacks(mgr, c.parsedArgs.Changed, cloudstack.NoFilter)
 	if err != nil {
 		fatal(err, "listing stacks globals: listing stacks")
 	}
 
+	if c.parsedArgs.Experimental.Globals.Trace {
+		c.printStacksGlobalsTrace(c.filterStacks(report.Stacks))
+		return
+	}
+
 	for _, stackEntry := range c.filterStacks(report.Stacks) {
 		stack := stackEntry.Stack
 		report := globals.ForStack(c.cfg(), stack)
@@ -1664,7 +1848,7 @@ func (c *cli) printMetadata() {
 	logger.Trace().
 		Msg("Create new terramate manager.")
 
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 	report, err := c.listStacks(mgr, c.parsedArgs.Changed, cloudstack.NoFilter)
 	if err != nil {
 		fatal(err, "loading metadata: listing stacks")
@@ -1728,7 +1912,7 @@ func (c *cli) checkGenCode() bool {
 }
 
 func (c *cli) ensureStackID() {
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 	report, err := c.listStacks(mgr, false, cloudstack.NoFilter)
 	if err != nil {
 		fatal(err, "listing stacks")
@@ -1749,13 +1933,10 @@ func (c *cli) ensureStackID() {
 }
 
 func (c *cli) eval() {
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.Eval.Global)
-	for _, exprStr := range c.parsedArgs.Experimental.Eval.Exprs {
-		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
-		if err != nil {
-			fatal(err)
-		}
-		val, err := ctx.Eval(expr)
+	exprs := parseCmdlineExprs(c.parsedArgs.Experimental.Eval.Exprs)
+	ctx := c.detectEvalContext(exprs...)
+	for i, exprStr := range c.parsedArgs.Experimental.Eval.Exprs {
+		val, err := ctx.EvalSensitive(exprs[i])
 		if err != nil {
 			fatal(err, "eval %q", exprStr)
 		}
@@ -1764,30 +1945,33 @@ func (c *cli) eval() {
 }
 
 func (c *cli) partialEval() {
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.PartialEval.Global)
-	for _, exprStr := range c.parsedArgs.Experimental.PartialEval.Exprs {
-		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
-		if err != nil {
-			fatal(err)
-		}
-		newexpr, err := ctx.PartialEval(expr)
+	exprs := parseCmdlineExprs(c.parsedArgs.Experimental.PartialEval.Exprs)
+	ctx := c.detectEvalContext(exprs...)
+	for i, exprStr := range c.parsedArgs.Experimental.PartialEval.Exprs {
+		newexpr, err := ctx.PartialEvalSensitive(exprs[i])
 		if err != nil {
 			fatal(err, "partial eval %q", exprStr)
 		}
+		newexpr = eval.RedactExpr(newexpr)
 		c.output.MsgStdOut(string(hclwrite.Format(ast.TokensForExpression(newexpr).Bytes())))
 	}
 }
 
 func (c *cli) evalRunArgs(st *config.Stack, cmd []string) []string {
-	ctx := c.setupEvalContext(st, map[string]string{})
-	var newargs []string
-	for _, arg := range cmd {
+	exprs := make([]hhcl.Expression, len(cmd))
+	for i, arg := range cmd {
 		exprStr := `"` + arg + `"`
 		expr, err := ast.ParseExpression(exprStr, "<cmd arg>")
 		if err != nil {
 			fatal(err, "parsing %s", exprStr)
 		}
-		val, err := ctx.Eval(expr)
+		exprs[i] = expr
+	}
+	ctx := c.setupEvalContext(st, exprs...)
+	var newargs []string
+	for i, arg := range cmd {
+		exprStr := `"` + arg + `"`
+		val, err := ctx.Eval(exprs[i])
 		if err != nil {
 			fatal(err, "eval %q", exprStr)
 		}
@@ -1805,13 +1989,10 @@ func (c *cli) getConfigValue() {
 		Str("action", "cli.getConfigValue()").
 		Logger()
 
-	ctx := c.detectEvalContext(c.parsedArgs.Experimental.GetConfigValue.Global)
-	for _, exprStr := range c.parsedArgs.Experimental.GetConfigValue.Vars {
-		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
-		if err != nil {
-			fatal(err)
-		}
-
+	exprs := parseCmdlineExprs(c.parsedArgs.Experimental.GetConfigValue.Vars)
+	ctx := c.detectEvalContext(exprs...)
+	for i, exprStr := range c.parsedArgs.Experimental.GetConfigValue.Vars {
+		expr := exprs[i]
 		iteratorTraversal, diags := hhcl.AbsTraversalForExpr(expr)
 		if diags.HasErrors() {
 			fatal(errors.E(diags), "expected a variable accessor")
@@ -1822,7 +2003,7 @@ func (c *cli) getConfigValue() {
 			logger.Fatal().Msg("only terramate and global variables are supported")
 		}
 
-		val, err := ctx.Eval(expr)
+		val, err := ctx.EvalSensitive(expr)
 		if err != nil {
 			fatal(err, "evaluating expression: %s", exprStr)
 		}
@@ -1832,6 +2013,8 @@ func (c *cli) getConfigValue() {
 }
 
 func (c *cli) outputEvalResult(val cty.Value, asJSON bool) {
+	val = eval.Redact(val)
+
 	var data []byte
 	if asJSON {
 		var err error
@@ -1851,7 +2034,20 @@ func (c *cli) outputEvalResult(val cty.Value, asJSON bool) {
 	c.output.MsgStdOut(string(data))
 }
 
-func (c *cli) detectEvalContext(overrideGlobals map[string]string) *eval.Context {
+// parseCmdlineExprs parses the expressions given in the command line.
+func parseCmdlineExprs(exprStrs []string) []hhcl.Expression {
+	exprs := make([]hhcl.Expression, len(exprStrs))
+	for i, exprStr := range exprStrs {
+		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
+		if err != nil {
+			fatal(err)
+		}
+		exprs[i] = expr
+	}
+	return exprs
+}
+
+func (c *cli) detectEvalContext(exprs ...hhcl.Expression) *eval.Context {
 	var st *config.Stack
 	if config.IsStack(c.cfg(), c.wd()) {
 		var err error
@@ -1860,53 +2056,38 @@ func (c *cli) detectEvalContext(overrideGlobals map[string]string) *eval.Context
 			fatal(err, "setup eval context: loading stack config")
 		}
 	}
-	return c.setupEvalContext(st, overrideGlobals)
+	return c.setupEvalContext(st, exprs...)
 }
 
-func (c *cli) setupEvalContext(st *config.Stack, overrideGlobals map[string]string) *eval.Context {
-	runtime := c.cfg().Runtime()
-
+// setupEvalContext creates the evaluation context for the given expressions,
+// evaluating only the globals referenced by them.
+func (c *cli) setupEvalContext(st *config.Stack, exprs ...hhcl.Expression) *eval.Context {
 	var tdir string
+	var ctx *eval.Context
 	if st != nil {
 		tdir = st.HostDir(c.cfg())
-		runtime.Merge(st.RuntimeValues(c.cfg()))
+		ctx = c.cfg().NewStackEvalContext(st, stdlib.NoFS(st.EvalDir(c.cfg())))
 	} else {
 		tdir = c.wd()
+		ctx = c.cfg().NewEvalContext(prj.PrjAbsPath(c.rootdir(), tdir), stdlib.NoFS(tdir))
 	}
 
-	ctx := eval.NewContext(stdlib.NoFS(tdir))
-	ctx.SetNamespace("terramate", runtime)
-
 	wdPath := prj.PrjAbsPath(c.rootdir(), tdir)
 	tree, ok := c.cfg().Lookup(wdPath)
 	if !ok {
 		fatal(errors.E("configuration at %s not found", wdPath))
 	}
-	exprs, err := globals.LoadExprs(tree)
+	globalExprs, err := globals.LoadExprs(tree)
 	if err != nil {
 		fatal(err, "loading globals expressions")
 	}
 
-	for name, exprStr := range overrideGlobals {
-		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
-		if err != nil {
-			fatal(errors.E(err, "--global %s=%s is an invalid expresssion", name, exprStr))
-		}
-		parts := strings.Split(name, ".")
-		length := len(parts)
-		globalPath := globals.NewGlobalAttrPath(parts[0:length-1], parts[length-1])
-		exprs.SetOverride(
-			wdPath,
-			globalPath,
-			expr,
-			info.NewRange(c.rootdir(), hhcl.Range{
-				Filename: "<eval argument>",
-				Start:    hhcl.InitialPos,
-				End:      hhcl.InitialPos,
-			}),
-		)
-	}
-	_ = exprs.Eval(ctx)
+	globalExprs.SetOverrides(wdPath, c.cfg().GlobalOverrides())
+	var traversals []hhcl.Traversal
+	for _, expr := range exprs {
+		traversals = append(traversals, expr.Variables()...)
+	}
+	_ = globalExprs.EvalOnly(ctx, traversals)
 	return ctx
 }
 
@@ -1965,8 +2146,19 @@ func (c *cli) gitSafeguardRemoteEnabled() bool {
 	return true
 }
 
-func (c *cli) wd() string           { return c.prj.wd }
-func (c *cli) rootdir() string      { return c.prj.rootdir }
+func (c *cli) wd() string      { return c.prj.wd }
+func (c *cli) rootdir() string { return c.prj.rootdir }
+
+// stackManager creates a stack manager for the project using the git refs
+// and the change detection cache configured for the CLI.
+func (c *cli) stackManager() *stack.Manager {
+	mgr := stack.NewManager(c.cfg(), c.prj.baseRef, c.prj.headRef)
+	if c.clicfg.EnableChangeCache {
+		mgr.EnableChangeCache(filepath.Join(c.clicfg.UserTerramateDir, "cache", "changes"))
+	}
+	return mgr
+}
+
 func (c *cli) cfg() *config.Root    { return &c.prj.root }
 func (c *cli) rootNode() hcl.Config { return c.prj.root.Tree().Node }
 func (c *cli) cred() credential     { return c.cloud.client.Credential.(credential) }
@@ -1983,7 +2175,7 @@ func (c *cli) computeSelectedStacks(ensureCleanRepo bool) (config.List[*config.S
 
 	logger.Trace().Msg("Create new terramate manager.")
 
-	mgr := stack.NewManager(c.cfg(), c.prj.baseRef)
+	mgr := c.stackManager()
 
 	logger.Trace().Msg("Get list of stacks.")
 
@@ -2180,7 +2372,7 @@ func newGit(basedir string, checkrepo bool) (*git.Git, error) {
 	return g, nil
 }
 
-func lookupProject(wd string) (prj project, found bool, err error) {
+func lookupProject(wd string, fetchImports bool) (prj project, found bool, err error) {
 	prj = project{
 		wd: wd,
 	}
@@ -2192,7 +2384,14 @@ func lookupProject(wd string) (prj project, found bool, err error) {
 
 	logger.Trace().Msg("Create new git wrapper.")
 
-	rootcfg, rootCfgPath, rootfound, err := config.TryLoadConfig(wd)
+	tryLoadConfig := config.TryLoadConfig
+	loadRoot := config.LoadRoot
+	if fetchImports {
+		tryLoadConfig = config.TryLoadConfigFetchingImports
+		loadRoot = config.LoadRootFetchingImports
+	}
+
+	rootcfg, rootCfgPath, rootfound, err := tryLoadConfig(wd)
 	if err != nil {
 		return project{}, false, err
 	}
@@ -2227,7 +2426,7 @@ func lookupProject(wd string) (prj project, found bool, err error) {
 
 			logger.Trace().Msg("Load root config.")
 
-			cfg, err := config.LoadRoot(rootdir)
+			cfg, err := loadRoot(rootdir)
 			if err != nil {
 				return project{}, false, err
 			}
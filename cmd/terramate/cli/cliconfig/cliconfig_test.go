@@ -93,6 +93,22 @@ func TestLoad(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "valid enable_change_cache",
+			cfg:  `enable_change_cache = true`,
+			want: want{
+				cfg: cliconfig.Config{
+					EnableChangeCache: true,
+				},
+			},
+		},
+		{
+			name: "set enable_change_cache to an invalid value",
+			cfg:  `enable_change_cache = "yes"`,
+			want: want{
+				err: errors.E(cliconfig.ErrInvalidAttributeType),
+			},
+		},
 		{
 			name: "disable_checkpoint and disable_checkpoint_signature",
 			cfg: `disable_checkpoint = true
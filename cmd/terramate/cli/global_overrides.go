@@ -0,0 +1,65 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// loadGlobalOverrides returns the globals overridden by the --globals-file
+// and --global flags. The --global flags have precedence over the file.
+func loadGlobalOverrides(rootdir, wd string, parsedArgs *cliSpec) ([]config.GlobalOverride, error) {
+	var overrides []config.GlobalOverride
+	if parsedArgs.GlobalsFile != "" {
+		filename := parsedArgs.GlobalsFile
+		if !filepath.IsAbs(filename) {
+			filename = filepath.Join(wd, filename)
+		}
+		fileOverrides, err := globals.LoadOverridesFile(rootdir, filename)
+		if err != nil {
+			return nil, errors.E(err, "--globals-file %s", parsedArgs.GlobalsFile)
+		}
+		overrides = append(overrides, fileOverrides...)
+	}
+
+	names := make([]string, 0, len(parsedArgs.Global))
+	for name := range parsedArgs.Global {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		exprStr := parsedArgs.Global[name]
+		parts := strings.Split(name, ".")
+		for _, part := range parts {
+			if !hclsyntax.ValidIdentifier(part) {
+				return nil, errors.E("--global %s=%s has an invalid global name", name, exprStr)
+			}
+		}
+		expr, err := ast.ParseExpression(exprStr, "<cmdline>")
+		if err != nil {
+			return nil, errors.E(err, "--global %s=%s is an invalid expresssion", name, exprStr)
+		}
+		overrides = append(overrides, config.GlobalOverride{
+			Path: parts,
+			Expr: expr,
+			Origin: info.NewRange(rootdir, hhcl.Range{
+				Filename: "<eval argument>",
+				Start:    hhcl.InitialPos,
+				End:      hhcl.InitialPos,
+			}),
+		})
+	}
+	return overrides, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package cli
+
+import (
+	stdjson "encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors/errlog"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type (
+	globalsTraceOutput struct {
+		Stacks []stackGlobalsTrace `json:"stacks"`
+	}
+
+	stackGlobalsTrace struct {
+		Path    string        `json:"path"`
+		Globals []globalTrace `json:"globals"`
+	}
+
+	globalTrace struct {
+		Name       string             `json:"name"`
+		Value      string             `json:"value,omitempty"`
+		Definition globalTraceEntry   `json:"definition"`
+		Overridden []globalTraceEntry `json:"overridden"`
+		Merged     []globalTraceEntry `json:"merged"`
+	}
+
+	globalTraceEntry struct {
+		Dir       string   `json:"dir"`
+		DefinedAt string   `json:"defined_at"`
+		Imports   []string `json:"imports"`
+		Unset     bool     `json:"unset"`
+	}
+)
+
+func (c *cli) printStacksGlobalsTrace(entries []stack.Entry) {
+	output := globalsTraceOutput{
+		Stacks: []stackGlobalsTrace{},
+	}
+
+	for _, entry := range entries {
+		st := entry.Stack
+		logger := log.With().
+			Stringer("stack", st.Dir).
+			Logger()
+
+		report := globals.ForStack(c.cfg(), st)
+		if err := report.AsError(); err != nil {
+			errlog.Fatal(logger, err, "tracing stacks globals: loading stack")
+		}
+
+		traces, err := globals.TraceForStack(c.cfg(), st)
+		if err != nil {
+			errlog.Fatal(logger, err, "tracing stacks globals")
+		}
+		if len(traces) == 0 {
+			continue
+		}
+
+		stackTrace := stackGlobalsTrace{
+			Path:    st.Dir.String(),
+			Globals: []globalTrace{},
+		}
+		for _, trace := range traces {
+			res := globalTrace{
+				Name:       "global." + trace.Name(),
+				Definition: newGlobalTraceEntry(trace.Definition),
+				Overridden: []globalTraceEntry{},
+				Merged:     []globalTraceEntry{},
+			}
+			if val, ok := report.Globals.GetKeyPath(trace.Path); ok {
+				res.Value = formatGlobalValue(val)
+			}
+			for _, overridden := range trace.Overridden {
+				res.Overridden = append(res.Overridden, newGlobalTraceEntry(overridden))
+			}
+			for _, merged := range trace.Merged {
+				res.Merged = append(res.Merged, newGlobalTraceEntry(merged))
+			}
+			stackTrace.Globals = append(stackTrace.Globals, res)
+		}
+		output.Stacks = append(output.Stacks, stackTrace)
+	}
+
+	if c.parsedArgs.Experimental.Globals.Format == "json" {
+		data, err := stdjson.MarshalIndent(output, "", "  ")
+		if err != nil {
+			fatal(err, "marshaling globals trace")
+		}
+		c.output.MsgStdOut(string(data))
+		return
+	}
+
+	for _, stackTrace := range output.Stacks {
+		c.output.MsgStdOut("\nstack %q:", stackTrace.Path)
+		for _, trace := range stackTrace.Globals {
+			if trace.Definition.Unset {
+				c.output.MsgStdOut("\t%s is unset", trace.Name)
+				c.output.MsgStdOut("\t\tunset at %s", trace.Definition)
+			} else {
+				c.output.MsgStdOut("\t%s = %s", trace.Name, trace.Value)
+				c.output.MsgStdOut("\t\tdefined at %s", trace.Definition)
+			}
+			for _, overridden := range trace.Overridden {
+				if overridden.Unset {
+					c.output.MsgStdOut("\t\toverrides the unset at %s", overridden)
+					continue
+				}
+				c.output.MsgStdOut("\t\toverrides %s", overridden)
+			}
+			for _, merged := range trace.Merged {
+				c.output.MsgStdOut("\t\tmerged with %s", merged)
+			}
+		}
+	}
+}
+
+func newGlobalTraceEntry(entry globals.TraceEntry) globalTraceEntry {
+	imports := []string{}
+	for _, file := range entry.Imports {
+		imports = append(imports, file.String())
+	}
+	return globalTraceEntry{
+		Dir:       entry.Dir.String(),
+		DefinedAt: entry.Origin.String(),
+		Imports:   imports,
+		Unset:     entry.Unset,
+	}
+}
+
+func (e globalTraceEntry) String() string {
+	s := e.DefinedAt
+	if len(e.Imports) > 0 {
+		s += fmt.Sprintf(" (imported by %s)", strings.Join(e.Imports, " -> "))
+	}
+	return s
+}
+
+func formatGlobalValue(val eval.Value) string {
+	var ctyval cty.Value
+	if val.IsObject() {
+		ctyval = cty.ObjectVal(val.(*eval.Object).AsValueMap())
+	} else {
+		ctyval = val.(eval.CtyValue).Raw()
+	}
+	ctyval = eval.Redact(ctyval)
+	return string(hclwrite.Format(ast.TokensForValue(ctyval).Bytes()))
+}
@@ -224,6 +224,19 @@ func (c *cli) RunAll(runStacks []ExecContext, isSuccessCode func(exitCode int) b
 			c.cloudSyncCancelStacks(runStacks[i+1:])
 			return errs.AsError()
 		}
+		if runContext.Stack.IsMatrixCombination() {
+			// stack matrix combinations may not have been generated yet.
+			err := os.MkdirAll(runContext.Stack.HostDir(c.cfg()), 0755)
+			if err != nil {
+				c.cloudSyncAfter(runContext, RunResult{ExitCode: -1}, err)
+				errs.Append(errors.E(err, "creating stack %s directory", runContext.Stack.Dir))
+				if continueOnError {
+					continue
+				}
+				c.cloudSyncCancelStacks(runStacks[i+1:])
+				return errs.AsError()
+			}
+		}
 		cmd := exec.Command(cmdPath, runContext.Cmd[1:]...)
 		cmd.Dir = runContext.Stack.HostDir(c.cfg())
 		cmd.Env = environ
@@ -0,0 +1,55 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"sync"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Cache is a concurrency safe cache of values computed from the configuration
+// of a [Root], like the evaluated globals shared by many stacks. It's reset
+// whenever the configuration tree changes.
+type Cache struct {
+	mu     sync.Mutex
+	values map[string]cty.Value
+}
+
+func newCache() *Cache {
+	return &Cache{
+		values: map[string]cty.Value{},
+	}
+}
+
+// Load returns the value cached for the key, if any.
+func (c *Cache) Load(key string) (cty.Value, bool) {
+	if c == nil {
+		return cty.NilVal, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	val, ok := c.values[key]
+	return val, ok
+}
+
+// Store caches the value for the key.
+func (c *Cache) Store(key string, val cty.Value) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values[key] = val
+}
+
+// Reset drops all the cached values.
+func (c *Cache) Reset() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.values = map[string]cty.Value{}
+}
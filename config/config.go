@@ -39,6 +39,10 @@ type Root struct {
 	tree Tree
 
 	runtime project.Runtime
+
+	cache *Cache
+
+	globalOverrides []GlobalOverride
 }
 
 // Tree is the configuration tree.
@@ -72,6 +76,16 @@ type List[T DirElem] []T
 // If the configuration is found, it returns the whole configuration tree,
 // configpath != "" and found as true.
 func TryLoadConfig(fromdir string) (tree *Root, configpath string, found bool, err error) {
+	return tryLoadConfig(fromdir, hcl.ParseDirWithInheritedTags)
+}
+
+// TryLoadConfigFetchingImports is like TryLoadConfig but the remote import
+// sources of the configuration tree which are not cached yet are fetched.
+func TryLoadConfigFetchingImports(fromdir string) (tree *Root, configpath string, found bool, err error) {
+	return tryLoadConfig(fromdir, hcl.ParseDirFetchingImports)
+}
+
+func tryLoadConfig(fromdir string, parse parseDirFunc) (tree *Root, configpath string, found bool, err error) {
 	for {
 		logger := log.With().
 			Str("action", "config.TryLoadConfig()").
@@ -84,12 +98,12 @@ func TryLoadConfig(fromdir string) (tree *Root, configpath string, found bool, e
 		if err != nil {
 			// the imports only works for the correct rootdir.
 			// As we are looking for the rootdir, we should ignore ErrImport
-			// errors.
-			if !errors.IsKind(err, hcl.ErrImport) {
+			// errors, and the remote imports are cached inside the rootdir.
+			if !errors.IsKind(err, hcl.ErrImport) && !errors.IsKind(err, hcl.ErrImportNotFetched) {
 				return nil, "", false, err
 			}
 		} else if cfg.Terramate != nil && cfg.Terramate.Config != nil {
-			tree, err := loadTree(fromdir, fromdir, &cfg)
+			tree, err := loadTree(fromdir, fromdir, &cfg, nil, parse)
 			if err != nil {
 				return nil, fromdir, true, err
 			}
@@ -108,8 +122,10 @@ func TryLoadConfig(fromdir string) (tree *Root, configpath string, found bool, e
 // NewRoot creates a new [Root] tree for the cfg tree.
 func NewRoot(tree *Tree) *Root {
 	r := &Root{
-		tree: *tree,
+		tree:  *tree,
+		cache: newCache(),
 	}
+	r.tree.applyStackDefaults(nil)
 	r.initRuntime()
 	return r
 }
@@ -123,6 +139,17 @@ func LoadRoot(rootdir string) (*Root, error) {
 	return NewRoot(cfgtree), nil
 }
 
+// LoadRootFetchingImports is like LoadRoot but the remote import sources
+// which are not cached yet are fetched, instead of failing with
+// hcl.ErrImportNotFetched.
+func LoadRootFetchingImports(rootdir string) (*Root, error) {
+	cfgtree, err := loadTree(rootdir, rootdir, nil, nil, hcl.ParseDirFetchingImports)
+	if err != nil {
+		return nil, err
+	}
+	return NewRoot(cfgtree), nil
+}
+
 // Tree returns the root configuration tree.
 func (root *Root) Tree() *Tree { return &root.tree }
 
@@ -189,7 +216,7 @@ func (root *Root) StacksByTagsFilters(filters []string) (project.Paths, error) {
 		if !hasFilter || !tree.IsStack() {
 			return false
 		}
-		return filter.MatchTags(clauses, tree.Node.Stack.Tags)
+		return filter.MatchTags(clauses, tree.Node.Stack.EffectiveTags())
 	}).Paths(), nil
 }
 
@@ -220,7 +247,11 @@ func (root *Root) LoadSubTree(cfgdir project.Path) error {
 	nextComponent := components[0]
 	subtreeDir := filepath.Join(rootdir, parent.String(), nextComponent)
 
-	node, err := LoadTree(rootdir, subtreeDir)
+	var inherited []hcl.InheritedTag
+	if subtreeDir != rootdir {
+		inherited = parentNode.inheritedTags()
+	}
+	node, err := loadTree(rootdir, subtreeDir, nil, inherited, hcl.ParseDirWithInheritedTags)
 	if err != nil {
 		return errors.E(err, "failed to load config from %s", subtreeDir)
 	}
@@ -231,10 +262,22 @@ func (root *Root) LoadSubTree(cfgdir project.Path) error {
 	} else {
 		node.Parent = parentNode
 		parentNode.Children[nextComponent] = node
+		if parentNode.IsStackMatrix() {
+			if err := parentNode.expandStackMatrix(); err != nil {
+				return err
+			}
+			node = parentNode
+		}
+		node.applyStackDefaults(node.Parent.inheritedTags())
+		root.initRuntime()
+		root.cache.Reset()
 	}
 	return nil
 }
 
+// Cache returns the cache of values computed from the root configuration.
+func (root *Root) Cache() *Cache { return root.cache }
+
 // Stacks return the stacks paths.
 func (root *Root) Stacks() project.Paths {
 	return root.tree.Stacks().Paths()
@@ -262,7 +305,8 @@ func (root *Root) initRuntime() {
 		"path": rootpath,
 	})
 	stacksNs := cty.ObjectVal(map[string]cty.Value{
-		"list": toCtyStringList(root.Stacks().Strings()),
+		"list":    toCtyStringList(root.Stacks().Strings()),
+		"objects": root.stacksObjectsValue(),
 	})
 	root.runtime = project.Runtime{
 		"root":    rootNS,
@@ -274,7 +318,7 @@ func (root *Root) initRuntime() {
 // LoadTree loads the whole hierarchical configuration from cfgdir downwards
 // using rootdir as project root.
 func LoadTree(rootdir string, cfgdir string) (*Tree, error) {
-	return loadTree(rootdir, cfgdir, nil)
+	return loadTree(rootdir, cfgdir, nil, nil, hcl.ParseDirWithInheritedTags)
 }
 
 // HostDir is the node absolute directory in the host.
@@ -304,8 +348,15 @@ func (tree *Tree) Root() *Root {
 }
 
 // IsStack tells if the node is a stack.
+// A stack matrix definition is not a stack itself, but each of its
+// combinations are.
 func (tree *Tree) IsStack() bool {
-	return tree.Node.Stack != nil
+	return tree.Node.Stack != nil && !tree.Node.Stack.IsMatrix()
+}
+
+// IsStackMatrix returns true if the tree node defines a stack matrix.
+func (tree *Tree) IsStackMatrix() bool {
+	return tree.Node.Stack != nil && tree.Node.Stack.IsMatrix()
 }
 
 // Stacks returns the stack nodes from the tree.
@@ -367,7 +418,19 @@ func (l List[T]) Len() int           { return len(l) }
 func (l List[T]) Less(i, j int) bool { return l[i].Dir().String() < l[j].Dir().String() }
 func (l List[T]) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
 
-func loadTree(rootdir string, cfgdir string, rootcfg *hcl.Config) (_ *Tree, err error) {
+// parseDirFunc parses the configuration of a directory.
+type parseDirFunc func(root string, dir string, inherited []hcl.InheritedTag) (hcl.Config, error)
+
+// loadTree loads the configuration tree of cfgdir, where inherited are the tags
+// inherited by cfgdir from the stack_defaults blocks of its parent directories.
+// The configuration of each directory is parsed with the parse function.
+func loadTree(
+	rootdir string,
+	cfgdir string,
+	rootcfg *hcl.Config,
+	inherited []hcl.InheritedTag,
+	parse parseDirFunc,
+) (_ *Tree, err error) {
 	logger := log.With().
 		Str("action", "config.loadTree()").
 		Str("dir", rootdir).
@@ -400,12 +463,15 @@ func loadTree(rootdir string, cfgdir string, rootcfg *hcl.Config) (_ *Tree, err
 	if rootcfg != nil {
 		tree.Node = *rootcfg
 	} else {
-		cfg, err := hcl.ParseDir(rootdir, cfgdir)
+		cfg, err := parse(rootdir, cfgdir, inherited)
 		if err != nil {
 			return nil, err
 		}
 		tree.Node = cfg
 	}
+	if tree.Node.StackDefaults != nil {
+		inherited = appendInheritedTags(inherited, tree.Node.StackDefaults.Tags)
+	}
 
 	for _, name := range names {
 		logger = logger.With().
@@ -428,7 +494,7 @@ func loadTree(rootdir string, cfgdir string, rootcfg *hcl.Config) (_ *Tree, err
 
 		logger.Trace().Msg("loading children tree")
 
-		node, err := LoadTree(rootdir, dir)
+		node, err := loadTree(rootdir, dir, nil, inherited, parse)
 		if err != nil {
 			return nil, errors.E(err, "loading from %s", dir)
 		}
@@ -436,18 +502,103 @@ func loadTree(rootdir string, cfgdir string, rootcfg *hcl.Config) (_ *Tree, err
 		node.Parent = tree
 		tree.Children[name] = node
 	}
+
+	if tree.IsStackMatrix() {
+		if err := tree.expandStackMatrix(); err != nil {
+			return nil, err
+		}
+	}
 	return tree, nil
 }
 
+// expandStackMatrix creates a child stack node for each combination of the
+// stack matrix defined in the tree node. The combination directories don't
+// need to exist in the file system but if they do, they must not define a
+// stack.
+func (tree *Tree) expandStackMatrix() error {
+	stacks, err := tree.Node.Stack.ExpandMatrix(filepath.Base(tree.dir))
+	if err != nil {
+		return errors.E(ErrSchema, err, "expanding stack matrix at %s", tree.Dir())
+	}
+	for name, stack := range stacks {
+		child, ok := tree.Children[name]
+		if !ok {
+			dir := filepath.Join(tree.dir, name)
+			child = NewTree(dir)
+			child.Node = hcl.NewStackMatrixConfig(dir, stack)
+			child.Parent = tree
+			tree.Children[name] = child
+			continue
+		}
+		if child.Node.Stack != nil && child.Node.Stack.Each == nil {
+			return errors.E(ErrSchema,
+				"stack matrix at %s conflicts with the stack defined at %s",
+				tree.Dir(), child.Dir())
+		}
+		child.Node.Stack = stack
+	}
+	return nil
+}
+
+// applyStackDefaults sets the tags inherited by the stacks of the tree from
+// the stack_defaults blocks of the tree directories and the provided ancestor
+// tags, which are ordered from the outermost directory.
+func (tree *Tree) applyStackDefaults(inherited []hcl.InheritedTag) {
+	if tree.Node.StackDefaults != nil {
+		inherited = appendInheritedTags(inherited, tree.Node.StackDefaults.Tags)
+	}
+	if tree.Node.Stack != nil {
+		tree.Node.Stack.InheritedTags = inherited
+	}
+	for _, child := range tree.Children {
+		child.applyStackDefaults(inherited)
+	}
+}
+
+// inheritedTags returns the tags declared by the stack_defaults blocks of the
+// tree directory and of its parent directories, ordered from the outermost.
+func (tree *Tree) inheritedTags() []hcl.InheritedTag {
+	if tree == nil {
+		return nil
+	}
+	tags := tree.Parent.inheritedTags()
+	if tree.Node.StackDefaults != nil {
+		tags = appendInheritedTags(tags, tree.Node.StackDefaults.Tags)
+	}
+	return tags
+}
+
+// appendInheritedTags returns a new list with the tags not yet inherited
+// appended, then a tag declared again in a subdirectory keeps its outermost
+// origin.
+func appendInheritedTags(inherited, tags []hcl.InheritedTag) []hcl.InheritedTag {
+	res := make([]hcl.InheritedTag, len(inherited), len(inherited)+len(tags))
+	copy(res, inherited)
+	for _, tag := range tags {
+		found := false
+		for _, other := range inherited {
+			if other.Name == tag.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, tag)
+		}
+	}
+	return res
+}
+
 // IsEmptyConfig tells if the configuration is empty.
 func (tree *Tree) IsEmptyConfig() bool {
 	return tree.Node.IsEmpty()
 }
 
-// NonEmptyGlobalsParent returns a parent configuration which has globals defined, if any.
+// NonEmptyGlobalsParent returns a parent configuration which has globals (or
+// global schemas) defined, if any.
 func (tree *Tree) NonEmptyGlobalsParent() *Tree {
 	parent := tree.Parent
-	for parent != nil && !parent.Node.HasGlobals() {
+	for parent != nil && !parent.Node.HasGlobals() && len(parent.Node.GlobalSchemas) == 0 {
 		parent = parent.Parent
 	}
 	return parent
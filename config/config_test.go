@@ -6,14 +6,20 @@ package config_test
 import (
 	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/madlambda/spells/assert"
 	"github.com/rs/zerolog"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
 	"github.com/terramate-io/terramate/project"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	. "github.com/terramate-io/terramate/test/hclutils"
 	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestIsStack(t *testing.T) {
@@ -337,3 +343,367 @@ func isStack(root *config.Root, dir string) bool {
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
+
+func TestStackMatrix(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/stack.tm.hcl:stack {
+		  id    = "infra"
+		  after = ["../network"]
+		  matrix {
+		    region = ["us", "eu"]
+		    env    = ["dev", "prd"]
+		  }
+		}`,
+		"s:network",
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	infra, ok := root.Lookup(project.NewPath("/infra"))
+	assert.IsTrue(t, ok && infra.IsStackMatrix() && !infra.IsStack())
+
+	assert.EqualInts(t, 5, len(root.Stacks()))
+	for _, comb := range []struct {
+		dir  string
+		each map[string]string
+	}{
+		{dir: "dev-eu", each: map[string]string{"env": "dev", "region": "eu"}},
+		{dir: "dev-us", each: map[string]string{"env": "dev", "region": "us"}},
+		{dir: "prd-eu", each: map[string]string{"env": "prd", "region": "eu"}},
+		{dir: "prd-us", each: map[string]string{"env": "prd", "region": "us"}},
+	} {
+		st, err := config.LoadStack(root, project.NewPath("/infra/"+comb.dir))
+		assert.NoError(t, err)
+		assert.EqualStrings(t, "infra-"+comb.dir, st.Name)
+		assert.EqualStrings(t, "infra-"+comb.dir, st.ID)
+		assert.EqualInts(t, 1, len(st.After))
+		assert.EqualStrings(t, "../../network", st.After[0])
+		assert.EqualInts(t, len(comb.each), len(st.Each))
+		for k, v := range comb.each {
+			assert.EqualStrings(t, v, st.Each[k])
+		}
+	}
+}
+
+func TestStackMatrixLongIDs(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/stack.tm.hcl:stack {
+		  id = "infrastructure-of-the-application"
+		  matrix {
+		    region = ["us-east-1", "eu-central-1"]
+		    env    = ["development", "production"]
+		    tier   = ["frontend"]
+		  }
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	ids := map[string]struct{}{}
+	for _, comb := range []string{
+		"development-eu-central-1-frontend",
+		"development-us-east-1-frontend",
+		"production-eu-central-1-frontend",
+		"production-us-east-1-frontend",
+	} {
+		st, err := config.LoadStack(root, project.NewPath("/infra/"+comb))
+		assert.NoError(t, err)
+		assert.IsTrue(t, len(st.ID) <= 64, "ID %q is too long", st.ID)
+		assert.IsTrue(t, strings.HasPrefix(st.ID, "infrastructure-of-the-application-"))
+		ids[st.ID] = struct{}{}
+	}
+	assert.EqualInts(t, 4, len(ids))
+}
+
+func TestStackMatrixInvalidValue(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/stack.tm.hcl:stack {
+		  id = "infra"
+		  matrix {
+		    version = ["1.5"]
+		  }
+		}`,
+	})
+
+	_, err := config.LoadRoot(s.RootDir())
+	assert.IsError(t, err, errors.E(hcl.ErrTerramateSchema))
+}
+
+func TestStackMatrixConflicts(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/stack.tm.hcl:stack {
+		  matrix {
+		    env = ["dev", "prd"]
+		  }
+		}`,
+		"s:infra/dev",
+	})
+
+	_, err := config.LoadRoot(s.RootDir())
+	assert.IsError(t, err, errors.E(config.ErrSchema))
+}
+
+func TestStackDefaultsTags(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/defaults.tm.hcl:stack_defaults {
+		  tags = ["infra", "team-a"]
+		}`,
+		`f:infra/prod/defaults.tm.hcl:stack_defaults {
+		  tags = ["prod", "infra"]
+		}`,
+		`s:infra/prod/app:tags=["app","prod"]`,
+		"s:infra/dev",
+		"s:other",
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		dir  string
+		tags []string
+	}{
+		{dir: "/infra/prod/app", tags: []string{"app", "prod", "infra", "team-a"}},
+		{dir: "/infra/dev", tags: []string{"infra", "team-a"}},
+		{dir: "/other", tags: nil},
+	} {
+		st, err := config.LoadStack(root, project.NewPath(tc.dir))
+		assert.NoError(t, err)
+		assert.EqualInts(t, len(tc.tags), len(st.Tags), "stack %s: %v", tc.dir, st.Tags)
+		for i, tag := range tc.tags {
+			assert.EqualStrings(t, tag, st.Tags[i])
+		}
+	}
+
+	app, _ := root.Lookup(project.NewPath("/infra/prod/app"))
+	inherited := app.Node.Stack.InheritedTags
+	assert.EqualInts(t, 3, len(inherited))
+	assert.EqualStrings(t, "/infra/defaults.tm.hcl", inherited[0].Origin.Path().String())
+	assert.EqualStrings(t, "/infra/prod/defaults.tm.hcl", inherited[2].Origin.Path().String())
+
+	paths, err := root.StacksByTagsFilters([]string{"team-a"})
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(paths))
+	paths.Sort()
+	assert.EqualStrings(t, "/infra/dev", paths[0].String())
+	assert.EqualStrings(t, "/infra/prod/app", paths[1].String())
+}
+
+func TestStackDefaultsTagsInImportConditions(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:infra/defaults.tm.hcl:stack_defaults {
+		  tags = ["aws"]
+		}`,
+		`f:imports/aws.tm.hcl:globals {
+		  cloud = "aws"
+		}`,
+		"s:infra/vpc",
+		"s:other",
+		`f:infra/vpc/import.tm.hcl:import {
+		  source    = "/imports/aws.tm.hcl"
+		  condition = tm_contains(terramate.stack.tags, "aws")
+		}`,
+		`f:other/import.tm.hcl:import {
+		  source    = "/imports/aws.tm.hcl"
+		  condition = tm_contains(terramate.stack.tags, "aws")
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	vpc, _ := root.Lookup(project.NewPath("/infra/vpc"))
+	assert.EqualInts(t, 1, len(vpc.Node.ImportedFiles))
+	other, _ := root.Lookup(project.NewPath("/other"))
+	assert.EqualInts(t, 0, len(other.Node.ImportedFiles))
+}
+
+func TestStackDefaultsInvalidTag(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:defaults.tm.hcl:stack_defaults {
+		  tags = ["Invalid Tag"]
+		}`,
+		"s:stack",
+	})
+
+	_, err := config.LoadRoot(s.RootDir())
+	assert.IsError(t, err, errors.E(hcl.ErrTerramateSchema))
+}
+
+func TestStackPolicies(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm.hcl:terramate {
+		  config {
+		    stacks {
+		      allowed_tags  = ["prod", "dev", "team-a"]
+		      required_tags = ["team-a"]
+		      name_pattern  = "^[a-z-]+$"
+		      require_id    = true
+		    }
+		  }
+		}`,
+		`s:ok:id=ok;tags=["team-a","prod"]`,
+		"f:bad_name/stack.tm:stack {\n  id   = \"bad\"\n  name = \"Bad\"\n  tags = [\"team-a\"]\n}\n",
+		"f:bad-tags/stack.tm:stack {\n  id   = \"tags\"\n  tags = [\"production\"]\n}\n",
+		"f:no-id/stack.tm:stack {\n  tags = [\"team-a\"]\n}\n",
+		"f:inherited/defaults.tm:stack_defaults {\n  tags = [\"staging\"]\n}\n",
+		"f:inherited/stack/stack.tm:stack {\n  id   = \"inherited\"\n  tags = [\"team-a\"]\n}\n",
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err, "policies must not be enforced when loading the project")
+
+	_, err = config.LoadAllStacks(root.Tree())
+	assert.NoError(t, err)
+
+	err = root.Tree().ValidateStacksPolicy()
+	assert.IsError(t, err, errors.E(config.ErrStackPolicy))
+
+	want := []error{
+		errors.E(config.ErrStackPolicy, Mkrange("bad_name/stack.tm", Start(3, 3, 25), End(3, 15, 37))),
+		errors.E(config.ErrStackPolicy, Mkrange("bad-tags/stack.tm", Start(3, 3, 26), End(3, 24, 47))),
+		errors.E(config.ErrStackPolicy, Mkrange("inherited/defaults.tm", Start(2, 3, 19), End(2, 21, 37))),
+		errors.E(config.ErrStackPolicy, Mkrange("no-id/stack.tm", Start(1, 1, 0), End(3, 2, 29))),
+	}
+	FixupFiledirOnErrorsFileRanges(s.RootDir(), want)
+	errtest.AssertErrorList(t, err, want)
+
+	var errs *errors.List
+	assert.IsTrue(t, errors.As(err, &errs))
+	assert.EqualInts(t, 5, len(errs.Errors()), "violations: %v", err)
+
+	for _, tc := range []struct {
+		dir        string
+		violations int
+	}{
+		{dir: "/ok", violations: 0},
+		{dir: "/bad_name", violations: 1},
+		{dir: "/bad-tags", violations: 2},
+		{dir: "/no-id", violations: 1},
+		{dir: "/inherited/stack", violations: 1},
+	} {
+		node, ok := root.Lookup(project.NewPath(tc.dir))
+		assert.IsTrue(t, ok, "stack %s not found", tc.dir)
+
+		err := node.ValidateStackPolicy()
+		if tc.violations == 0 {
+			assert.NoError(t, err, "stack %s", tc.dir)
+			continue
+		}
+		assert.IsError(t, err, errors.E(config.ErrStackPolicy))
+
+		var errs *errors.List
+		assert.IsTrue(t, errors.As(err, &errs), "stack %s: want error list", tc.dir)
+		assert.EqualInts(t, tc.violations, len(errs.Errors()), "stack %s: %v", tc.dir, err)
+	}
+}
+
+func TestStackRelationMetadata(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"f:shared/file.txt:shared",
+		"s:app",
+		`s:app/api:after=["../../db"];wants=["id:cache-id"];watch=["/shared/file.txt"]`,
+		`s:app/nested/worker:before=["tag:web"]`,
+		"s:cache:id=cache-id",
+		"s:db",
+		`s:web:tags=["web"]`,
+	})
+
+	root := s.Config()
+	metadata := func(dir string) map[string]interface{} {
+		st, err := config.LoadStack(root, project.NewPath(dir))
+		assert.NoError(t, err)
+		stack := st.RuntimeValues(root)["stack"].AsValueMap()
+		strs := func(val cty.Value) []string {
+			var list []string
+			for _, elem := range val.AsValueSlice() {
+				list = append(list, elem.AsString())
+			}
+			return list
+		}
+		deps := stack["dependencies"].AsValueMap()
+		return map[string]interface{}{
+			"parent":    stack["parent"].AsString(),
+			"children":  strs(stack["children"]),
+			"after":     strs(deps["after"]),
+			"before":    strs(deps["before"]),
+			"wants":     strs(deps["wants"]),
+			"wanted_by": strs(deps["wanted_by"]),
+			"watch":     strs(stack["watch"]),
+		}
+	}
+
+	for _, tc := range []struct {
+		dir  string
+		want map[string]interface{}
+	}{
+		{
+			dir: "/app",
+			want: map[string]interface{}{
+				"parent":   "",
+				"children": []string{"/app/api", "/app/nested/worker"},
+			},
+		},
+		{
+			dir: "/app/api",
+			want: map[string]interface{}{
+				"parent": "/app",
+				"after":  []string{"/db"},
+				"wants":  []string{"/cache"},
+				"watch":  []string{"/shared/file.txt"},
+			},
+		},
+		{
+			dir: "/app/nested/worker",
+			want: map[string]interface{}{
+				"parent": "/app",
+				"before": []string{"/web"},
+			},
+		},
+	} {
+		got := metadata(tc.dir)
+		for name, val := range got {
+			want, ok := tc.want[name]
+			if !ok {
+				want = []string(nil)
+			}
+			if diff := cmp.Diff(want, val); diff != "" {
+				t.Errorf("%s: terramate.stack %s mismatch: %s", tc.dir, name, diff)
+			}
+		}
+	}
+
+	stacks := root.Runtime()["stacks"]
+	list := stacks.GetAttr("list").AsValueSlice()
+	objects := stacks.GetAttr("objects").AsValueSlice()
+	assert.EqualInts(t, 6, len(list))
+	assert.EqualInts(t, 6, len(objects))
+	for i, obj := range objects {
+		assert.EqualStrings(t, list[i].AsString(), obj.GetAttr("path").AsString())
+	}
+	web := objects[5].AsValueMap()
+	assert.EqualStrings(t, "/web", web["path"].AsString())
+	assert.EqualStrings(t, "web", web["tags"].Index(cty.NumberIntVal(0)).AsString())
+	cache := objects[3].AsValueMap()
+	assert.EqualStrings(t, "cache-id", cache["id"].AsString())
+}
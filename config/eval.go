@@ -0,0 +1,38 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// NewEvalContext creates an evaluation context for the dir directory with the
+// given functions, the terramate namespace of the project and the user
+// functions visible from dir.
+func (root *Root) NewEvalContext(dir project.Path, funcs map[string]function.Function) *eval.Context {
+	ctx := eval.NewContext(funcs)
+	ctx.SetNamespace("terramate", root.Runtime())
+	root.SetLazyRuntime(ctx, nil)
+	root.SetFunctions(ctx, dir)
+	return ctx
+}
+
+// NewStackEvalContext creates an evaluation context for the stack with the
+// given functions, the terramate namespace with the stack metadata, the user
+// functions visible from the stack and, for stacks created from a stack
+// matrix, the each namespace.
+func (root *Root) NewStackEvalContext(st *Stack, funcs map[string]function.Function) *eval.Context {
+	ctx := eval.NewContext(funcs)
+	runtime := root.Runtime()
+	runtime.Merge(st.RuntimeValues(root))
+	ctx.SetNamespace("terramate", runtime)
+	root.SetLazyRuntime(ctx, st)
+	root.SetFunctions(ctx, st.Dir)
+	if each := st.EachValues(); each != nil {
+		ctx.SetNamespace("each", each)
+	}
+	return ctx
+}
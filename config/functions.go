@@ -0,0 +1,154 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// Errors returned when calling user functions.
+const (
+	ErrFunctionCall      errors.Kind = "calling user function"
+	ErrFunctionRecursion errors.Kind = "recursive user function call"
+)
+
+// Functions returns the user functions visible from the tree directory, which
+// are the functions defined in the directory and in its parent directories,
+// indexed by their name in the evaluation context. A function defined in a
+// directory overrides the function with the same name of a parent directory.
+func (tree *Tree) Functions() map[string]hcl.FunctionConfig {
+	funcs := map[string]hcl.FunctionConfig{}
+	for ; tree != nil; tree = tree.Parent {
+		for _, fn := range tree.Node.Functions {
+			if _, ok := funcs[fn.FuncName()]; !ok {
+				funcs[fn.FuncName()] = fn
+			}
+		}
+	}
+	return funcs
+}
+
+// SetFunctions registers in the evaluation context the user functions visible
+// from the dir directory. The functions are evaluated with the functions of
+// the context, then they can call any other function available to the caller.
+func (root *Root) SetFunctions(ctx *eval.Context, dir project.Path) {
+	tree, ok := root.Lookup(dir)
+	if !ok {
+		return
+	}
+	funcs := tree.Functions()
+	if len(funcs) == 0 {
+		return
+	}
+	table := ctx.Unwrap().Functions
+	for name, fn := range funcs {
+		ctx.SetFunction(name, newUserFunction(fn, table, recursiveCalls(name, funcs)))
+	}
+}
+
+// recursiveCalls returns the chain of calls by which the function name ends up
+// calling itself, or nil if it's not recursive. The calls are found in the
+// result expressions, so the recursion is detected without evaluating them.
+func recursiveCalls(name string, funcs map[string]hcl.FunctionConfig) []string {
+	visited := map[string]bool{}
+	var visit func(calls []string) []string
+	visit = func(calls []string) []string {
+		fn := funcs[calls[len(calls)-1]]
+		for _, callee := range calledFunctions(fn.Result) {
+			if callee == name {
+				return append(calls, callee)
+			}
+			if _, ok := funcs[callee]; !ok || visited[callee] {
+				continue
+			}
+			visited[callee] = true
+			if chain := visit(append(calls[:len(calls):len(calls)], callee)); chain != nil {
+				return chain
+			}
+		}
+		return nil
+	}
+	return visit([]string{name})
+}
+
+// calledFunctions returns the names of the functions called by expr.
+func calledFunctions(expr hhcl.Expression) []string {
+	syntaxExpr, ok := expr.(hclsyntax.Expression)
+	if !ok {
+		return nil
+	}
+	var names []string
+	_ = hclsyntax.VisitAll(syntaxExpr, func(node hclsyntax.Node) hhcl.Diagnostics {
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			names = append(names, call.Name)
+		}
+		return nil
+	})
+	return names
+}
+
+func newUserFunction(fn hcl.FunctionConfig, table map[string]function.Function, recursion []string) function.Function {
+	params := make([]function.Parameter, len(fn.Params))
+	for i, name := range fn.Params {
+		params[i] = function.Parameter{
+			Name:             name,
+			Type:             cty.DynamicPseudoType,
+			AllowNull:        true,
+			AllowDynamicType: true,
+		}
+	}
+	return function.New(&function.Spec{
+		Params: params,
+		Type:   function.StaticReturnType(cty.DynamicPseudoType),
+		Impl: func(args []cty.Value, _ cty.Type) (cty.Value, error) {
+			if recursion != nil {
+				return cty.NilVal, errors.E(ErrFunctionRecursion,
+					"function %s calls itself: %s", fn.FuncName(), strings.Join(recursion, " -> "))
+			}
+
+			vars := make(map[string]cty.Value, len(args))
+			for i, arg := range args {
+				vars[fn.Params[i]] = arg
+			}
+			val, diags := fn.Result.Value(&hhcl.EvalContext{
+				Variables: vars,
+				Functions: table,
+			})
+			if diags.HasErrors() {
+				return cty.NilVal, errors.E(ErrFunctionCall, callErrors(diags),
+					"evaluating function %s", fn.FuncName())
+			}
+			return val, nil
+		},
+	})
+}
+
+// callErrors converts the diagnostics of evaluating a function result into
+// errors. The errors of the user functions called by the result are kept with
+// the range of the calling expression.
+func callErrors(diags hhcl.Diagnostics) error {
+	errs := errors.L()
+	for _, diag := range diags {
+		if diag.Severity != hhcl.DiagError {
+			continue
+		}
+		var callErr *errors.Error
+		extra, ok := hhcl.DiagnosticExtra[hclsyntax.FunctionCallDiagExtra](diag)
+		if ok && diag.Subject != nil && errors.As(extra.FunctionCallError(), &callErr) {
+			errs.Append(errors.E(*diag.Subject, callErr))
+			continue
+		}
+		errs.Append(errors.E(diag))
+	}
+	return errs.AsError()
+}
@@ -0,0 +1,125 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrGitMetadata indicates that the git metadata of the terramate namespace
+// could not be computed.
+const ErrGitMetadata errors.Kind = "computing git metadata"
+
+const defaultGitRemote = "origin"
+
+// SetLazyRuntime sets the runtime values of the terramate namespace which are
+// only computed when referenced, like the terramate.git metadata of the
+// project. If the stack is not nil, the lazy values of the stack, like
+// terramate.stack.git, are also set.
+//
+// The git metadata is computed at most once and shared by all the evaluations
+// using the same root.
+func (root *Root) SetLazyRuntime(ctx *eval.Context, st *Stack) {
+	ctx.SetLazyValue([]string{"terramate", "git", "commit"}, func() (cty.Value, error) {
+		return root.gitValue("git:commit", func(g *git.Git) (cty.Value, error) {
+			commit, err := g.RevParse("HEAD")
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.StringVal(commit), nil
+		})
+	})
+	ctx.SetLazyValue([]string{"terramate", "git", "branch"}, func() (cty.Value, error) {
+		return root.gitValue("git:branch", func(g *git.Git) (cty.Value, error) {
+			branch, err := g.CurrentBranch()
+			if err != nil {
+				// HEAD is detached.
+				return cty.StringVal(""), nil
+			}
+			return cty.StringVal(branch), nil
+		})
+	})
+	ctx.SetLazyValue([]string{"terramate", "git", "remote_url"}, func() (cty.Value, error) {
+		return root.gitValue("git:remote_url", func(g *git.Git) (cty.Value, error) {
+			url, err := g.URL(root.gitRemote())
+			if err != nil {
+				// the remote is not configured.
+				return cty.StringVal(""), nil
+			}
+			return cty.StringVal(url), nil
+		})
+	})
+	ctx.SetLazyValue([]string{"terramate", "git", "dirty"}, func() (cty.Value, error) {
+		return root.gitValue("git:dirty", func(g *git.Git) (cty.Value, error) {
+			changed, err := g.ListChangedFrom("HEAD")
+			if err != nil {
+				return cty.NilVal, err
+			}
+			untracked, err := g.ListUntracked()
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.BoolVal(len(changed) > 0 || len(untracked) > 0), nil
+		})
+	})
+	if st == nil {
+		return
+	}
+	ctx.SetLazyValue([]string{"terramate", "stack", "git", "last_commit"}, func() (cty.Value, error) {
+		return root.gitValue("git:last_commit:"+st.Dir.String(), func(g *git.Git) (cty.Value, error) {
+			stackdir := st.Dir
+			if st.IsMatrixCombination() {
+				stackdir = stackdir.Dir()
+			}
+			dir := strings.TrimPrefix(stackdir.String(), "/")
+			if dir == "" {
+				dir = "."
+			}
+			commit, err := g.LastCommitTouching(dir)
+			if err != nil {
+				return cty.NilVal, err
+			}
+			return cty.StringVal(commit), nil
+		})
+	})
+}
+
+// gitValue returns the cached git metadata value for the key, computing it
+// with fn if it's not cached yet.
+func (root *Root) gitValue(key string, fn func(g *git.Git) (cty.Value, error)) (cty.Value, error) {
+	if val, ok := root.cache.Load(key); ok {
+		return val, nil
+	}
+	g, err := git.WithConfig(git.Config{
+		WorkingDir: root.HostDir(),
+		Env:        os.Environ(),
+	})
+	if err != nil {
+		return cty.NilVal, errors.E(ErrGitMetadata, err)
+	}
+	if !g.IsRepository() {
+		return cty.NilVal, errors.E(ErrGitMetadata, "project is not a git repository")
+	}
+	val, err := fn(g)
+	if err != nil {
+		return cty.NilVal, errors.E(ErrGitMetadata, err)
+	}
+	root.cache.Store(key, val)
+	return val, nil
+}
+
+func (root *Root) gitRemote() string {
+	cfg := root.tree.Node.Terramate
+	if cfg != nil && cfg.Config != nil && cfg.Config.Git != nil &&
+		cfg.Config.Git.DefaultRemote != "" {
+		return cfg.Config.Git.DefaultRemote
+	}
+	return defaultGitRemote
+}
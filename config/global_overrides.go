@@ -0,0 +1,33 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// GlobalOverride is a global defined outside of the project configuration,
+// eg.: in the command line, which overrides the global in all directories.
+type GlobalOverride struct {
+	// Path is the global accessor path (labels + attribute name).
+	Path []string
+
+	// Expr is the expression of the global.
+	Expr hhcl.Expression
+
+	// Origin is where the expression was defined.
+	Origin info.Range
+}
+
+// SetGlobalOverrides sets the globals overriding the globals of the project.
+// When more than one override has the same path the last one is used.
+func (root *Root) SetGlobalOverrides(overrides []GlobalOverride) {
+	root.globalOverrides = overrides
+}
+
+// GlobalOverrides returns the globals overriding the globals of the project.
+func (root *Root) GlobalOverrides() []GlobalOverride {
+	return root.globalOverrides
+}
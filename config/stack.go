@@ -54,6 +54,10 @@ type (
 		// Watch is the list of files to be watched for changes.
 		Watch []project.Path
 
+		// Each is the combination of matrix values of a stack created from a
+		// stack matrix, or nil if the stack was not created from a matrix.
+		Each map[string]string
+
 		// IsChanged tells if this is a changed stack.
 		IsChanged bool
 	}
@@ -100,12 +104,13 @@ func NewStackFromHCL(root string, cfg hcl.Config) (*Stack, error) {
 		Name:        name,
 		ID:          cfg.Stack.ID,
 		Description: cfg.Stack.Description,
-		Tags:        cfg.Stack.Tags,
+		Tags:        cfg.Stack.EffectiveTags(),
 		After:       cfg.Stack.After,
 		Before:      cfg.Stack.Before,
 		Wants:       cfg.Stack.Wants,
 		WantedBy:    cfg.Stack.WantedBy,
 		Watch:       watchFiles,
+		Each:        cfg.Stack.Each,
 		Dir:         project.PrjAbsPath(root, cfg.AbsDir()),
 	}
 	err = stack.Validate()
@@ -216,6 +221,16 @@ func (s *Stack) HostDir(root *Root) string {
 	return project.AbsPath(root.HostDir(), s.Dir.String())
 }
 
+// EvalDir returns the host directory used as base directory by the functions
+// evaluated in the stack context. Stacks created from a stack matrix use the
+// matrix definition directory because their own directory may not exist.
+func (s *Stack) EvalDir(root *Root) string {
+	if s.IsMatrixCombination() {
+		return filepath.Dir(s.HostDir(root))
+	}
+	return s.HostDir(root)
+}
+
 // RuntimeValues returns the runtime "terramate" namespace for the stack.
 func (s *Stack) RuntimeValues(root *Root) map[string]cty.Value {
 	logger := log.With().
@@ -224,34 +239,32 @@ func (s *Stack) RuntimeValues(root *Root) map[string]cty.Value {
 
 	logger.Trace().Msg("creating stack metadata")
 
-	stackpath := cty.ObjectVal(map[string]cty.Value{
-		"absolute": cty.StringVal(s.Dir.String()),
-		"relative": cty.StringVal(s.RelPath()),
-		"basename": cty.StringVal(s.PathBase()),
-		"to_root":  cty.StringVal(s.RelPathToRoot(root)),
-	})
-	stackMapVals := map[string]cty.Value{
-		"name":        cty.StringVal(s.Name),
-		"description": cty.StringVal(s.Description),
-		"tags":        toCtyStringList(s.Tags),
-		"path":        stackpath,
-	}
-	if s.ID != "" {
-		logger.Trace().
-			Str("id", s.ID).
-			Msg("adding stack ID to metadata")
+	return project.StackRuntime(project.StackMetadata{
+		Dir:         s.Dir,
+		Name:        s.Name,
+		Description: s.Description,
+		ID:          s.ID,
+		Tags:        s.Tags,
+		ToRoot:      s.RelPathToRoot(root),
+	}, s.relationValues(root))
+}
 
-		stackMapVals["id"] = cty.StringVal(s.ID)
+// EachValues returns the "each" namespace of a stack created from a stack
+// matrix or nil if the stack was not created from a matrix.
+func (s *Stack) EachValues() map[string]cty.Value {
+	if s.Each == nil {
+		return nil
 	}
-	stack := cty.ObjectVal(stackMapVals)
-	return map[string]cty.Value{
-		"name":        cty.StringVal(s.Name),         // DEPRECATED
-		"path":        cty.StringVal(s.Dir.String()), // DEPRECATED
-		"description": cty.StringVal(s.Description),  // DEPRECATED
-		"stack":       stack,
+	each := make(map[string]cty.Value, len(s.Each))
+	for k, v := range s.Each {
+		each[k] = cty.StringVal(v)
 	}
+	return each
 }
 
+// IsMatrixCombination tells if the stack was created from a stack matrix.
+func (s *Stack) IsMatrixCombination() bool { return s.Each != nil }
+
 // Sortable returns an implementation of stack which can be sorted by [config.List].
 func (s *Stack) Sortable() *SortableStack {
 	return &SortableStack{
@@ -338,6 +351,9 @@ func LoadAllStacks(cfg *Tree) (List[*SortableStack], error) {
 		}
 	}
 
+	if err := validateStackIDRefs(cfg, stacks); err != nil {
+		return List[*SortableStack]{}, err
+	}
 	return stacks, nil
 }
 
@@ -350,7 +366,11 @@ func LoadStack(root *Root, dir project.Path) (*Stack, error) {
 	if !node.IsStack() {
 		return nil, errors.E("config at %q is not a stack", dir)
 	}
-	return NewStackFromHCL(root.HostDir(), node.Node)
+	stack, err := NewStackFromHCL(root.HostDir(), node.Node)
+	if err != nil {
+		return nil, err
+	}
+	return stack, nil
 }
 
 // TryLoadStack tries to load a single stack from dir. It sets found as true in case
@@ -0,0 +1,97 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
+)
+
+// StackIDRefPrefix is the prefix of the stack.after, stack.before, stack.wants
+// and stack.wanted_by entries referencing a stack by its ID.
+const StackIDRefPrefix = "id:"
+
+// ErrStackUnknownID indicates a stack references an ID not declared by any
+// stack.
+const ErrStackUnknownID errors.Kind = "unknown stack ID"
+
+// IsStackIDRef tells if the stack entry references a stack by its ID.
+func IsStackIDRef(entry string) bool {
+	return strings.HasPrefix(entry, StackIDRefPrefix)
+}
+
+// StackByID returns the stack node with the given ID. The IDs are case
+// insensitive.
+func (root *Root) StackByID(id string) (*Tree, bool) {
+	for _, node := range root.tree.Stacks() {
+		if node.Node.Stack.ID != "" && strings.EqualFold(node.Node.Stack.ID, id) {
+			return node, true
+		}
+	}
+	return nil, false
+}
+
+// ResolveStackIDRef returns the absolute project path of the stack referenced
+// by the id:<stack-id> entry of the given field of the stack. The error has the
+// range of the field if the ID is unknown.
+func (root *Root) ResolveStackIDRef(s *Stack, field, ref string) (project.Path, error) {
+	node, ok := root.StackByID(strings.TrimPrefix(ref, StackIDRefPrefix))
+	if !ok {
+		return project.Path{}, unknownStackIDErr(&root.tree, s, field, ref)
+	}
+	return node.Dir(), nil
+}
+
+// validateStackIDRefs checks that the id:<stack-id> entries of the stacks
+// reference existing stacks of the project.
+func validateStackIDRefs(cfg *Tree, stacks List[*SortableStack]) error {
+	for cfg.Parent != nil {
+		cfg = cfg.Parent
+	}
+	ids := map[string]struct{}{}
+	for _, node := range cfg.Stacks() {
+		if node.Node.Stack.ID != "" {
+			ids[strings.ToLower(node.Node.Stack.ID)] = struct{}{}
+		}
+	}
+
+	errs := errors.L()
+	for _, elem := range stacks {
+		s := elem.Stack
+		for _, field := range []struct {
+			name    string
+			entries []string
+		}{
+			{name: "after", entries: s.After},
+			{name: "before", entries: s.Before},
+			{name: "wants", entries: s.Wants},
+			{name: "wanted_by", entries: s.WantedBy},
+		} {
+			for _, entry := range field.entries {
+				if !IsStackIDRef(entry) {
+					continue
+				}
+				id := strings.TrimPrefix(entry, StackIDRefPrefix)
+				if _, ok := ids[strings.ToLower(id)]; !ok {
+					errs.Append(unknownStackIDErr(cfg, s, field.name, entry))
+				}
+			}
+		}
+	}
+	return errs.AsError()
+}
+
+func unknownStackIDErr(rootTree *Tree, s *Stack, field, ref string) error {
+	msg := fmt.Sprintf("stack %s: stack.%s entry %q references an unknown stack ID",
+		s.Dir, field, ref)
+	if node, ok := rootTree.lookup(s.Dir); ok && node.Node.Stack != nil {
+		if rng, ok := node.Node.Stack.AttrRanges[field]; ok {
+			return errors.E(ErrStackUnknownID, rng, msg)
+		}
+	}
+	return errors.E(ErrStackUnknownID, msg)
+}
@@ -0,0 +1,142 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// stacksObjectsValue returns the terramate.stacks.objects metadata, a list with
+// an object for each stack of the project, ordered by the stack path.
+func (root *Root) stacksObjectsValue() cty.Value {
+	stacks := root.tree.Stacks()
+	if len(stacks) == 0 {
+		return cty.ListValEmpty(cty.Object(map[string]cty.Type{
+			"path":        cty.String,
+			"id":          cty.String,
+			"name":        cty.String,
+			"description": cty.String,
+			"tags":        cty.List(cty.String),
+		}))
+	}
+	elems := make([]cty.Value, len(stacks))
+	for i, node := range stacks {
+		cfg := node.Node.Stack
+		name := cfg.Name
+		if name == "" {
+			name = filepath.Base(node.HostDir())
+		}
+		elems[i] = cty.ObjectVal(map[string]cty.Value{
+			"path":        cty.StringVal(node.Dir().String()),
+			"id":          cty.StringVal(cfg.ID),
+			"name":        cty.StringVal(name),
+			"description": cty.StringVal(cfg.Description),
+			"tags":        toCtyStringList(cfg.EffectiveTags()),
+		})
+	}
+	return cty.ListVal(elems)
+}
+
+// relationValues returns the metadata relating the stack with the other
+// stacks of the project: its parent and children stacks, its resolved
+// ordering and selection dependencies and its watched files.
+func (s *Stack) relationValues(root *Root) map[string]cty.Value {
+	var parent string
+	var children []string
+	if tree, ok := root.Lookup(s.Dir); ok {
+		for p := tree.Parent; p != nil; p = p.Parent {
+			if p.IsStack() {
+				parent = p.Dir().String()
+				break
+			}
+		}
+		children = childStacks(tree)
+	}
+
+	watch := make([]string, len(s.Watch))
+	for i, p := range s.Watch {
+		watch[i] = p.String()
+	}
+
+	return map[string]cty.Value{
+		"parent":   cty.StringVal(parent),
+		"children": toCtyStringList(children),
+		"dependencies": cty.ObjectVal(map[string]cty.Value{
+			"after":     toCtyStringList(root.resolveStackRefs(s, s.After)),
+			"before":    toCtyStringList(root.resolveStackRefs(s, s.Before)),
+			"wants":     toCtyStringList(root.resolveStackRefs(s, s.Wants)),
+			"wanted_by": toCtyStringList(root.resolveStackRefs(s, s.WantedBy)),
+		}),
+		"watch": toCtyStringList(watch),
+	}
+}
+
+// childStacks returns the paths of the stacks nested in the tree which have
+// no other stack between them and the tree directory.
+func childStacks(tree *Tree) []string {
+	var children []string
+	for _, child := range tree.Children {
+		if child.IsStack() {
+			children = append(children, child.Dir().String())
+			continue
+		}
+		children = append(children, childStacks(child)...)
+	}
+	sort.Strings(children)
+	return children
+}
+
+// resolveStackRefs returns the sorted paths of the stacks referenced by the
+// after, before, wants or wanted_by entries of the stack. The entries can be
+// stack paths, relative to the stack directory or absolute, id:<stack-id>
+// references or tag:<query> filters. Entries not matching any stack are
+// ignored, the same way as when ordering the stacks.
+func (root *Root) resolveStackRefs(s *Stack, entries []string) []string {
+	seen := map[string]struct{}{}
+	add := func(p project.Path) {
+		if p != s.Dir {
+			seen[p.String()] = struct{}{}
+		}
+	}
+	for _, entry := range entries {
+		switch {
+		case strings.HasPrefix(entry, "tag:"):
+			paths, err := root.StacksByTagsFilters([]string{strings.TrimPrefix(entry, "tag:")})
+			if err != nil {
+				continue
+			}
+			for _, p := range paths {
+				add(p)
+			}
+		case IsStackIDRef(entry):
+			if node, ok := root.StackByID(strings.TrimPrefix(entry, StackIDRefPrefix)); ok {
+				add(node.Dir())
+			}
+		default:
+			pathstr := entry
+			if !path.IsAbs(pathstr) {
+				pathstr = path.Join(s.Dir.String(), pathstr)
+			}
+			node, ok := root.Lookup(project.NewPath(pathstr))
+			if !ok {
+				continue
+			}
+			for _, st := range node.stacks((*Tree).IsStack) {
+				add(st.Dir())
+			}
+		}
+	}
+	paths := make([]string, 0, len(seen))
+	for p := range seen {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,117 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// ErrStackPolicy indicates the stack violates the policies of the
+// terramate.config.stacks block.
+const ErrStackPolicy errors.Kind = "stack policy violation"
+
+// StacksPolicy returns the policies enforced on all the stacks of the
+// project, or nil if the project has none.
+func (tree *Tree) StacksPolicy() *hcl.StacksConfig {
+	for tree.Parent != nil {
+		tree = tree.Parent
+	}
+	if tree.Node.Terramate == nil || tree.Node.Terramate.Config == nil {
+		return nil
+	}
+	return tree.Node.Terramate.Config.Stacks
+}
+
+// ValidateStacksPolicy checks all the stacks of the tree against the project
+// policy. The returned error is an [errors.List] with one error per violation.
+func (tree *Tree) ValidateStacksPolicy() error {
+	errs := errors.L()
+	for _, stack := range tree.Stacks() {
+		errs.Append(stack.ValidateStackPolicy())
+	}
+	return errs.AsError()
+}
+
+// ValidateStackPolicy checks the stack of the tree node against the project
+// policy. The returned error is an [errors.List] with one error per violation,
+// which range is the range of the offending stack attribute, or of the
+// stack_defaults.tags attribute for inherited tags, falling back to the stack
+// block when the attribute is not set.
+func (tree *Tree) ValidateStackPolicy() error {
+	policy := tree.StacksPolicy()
+	if policy == nil || !tree.IsStack() {
+		return nil
+	}
+
+	stack := tree.Node.Stack
+	dir := tree.Dir()
+	attrRange := func(name string) info.Range {
+		if rng, ok := stack.AttrRanges[name]; ok {
+			return rng
+		}
+		return stack.Range
+	}
+	tagRange := func(name string) info.Range {
+		for _, tag := range stack.Tags {
+			if tag == name {
+				return attrRange("tags")
+			}
+		}
+		for _, inherited := range stack.InheritedTags {
+			if inherited.Name == name {
+				return inherited.Origin
+			}
+		}
+		return attrRange("tags")
+	}
+
+	errs := errors.L()
+	tags := stack.EffectiveTags()
+	if len(policy.AllowedTags) > 0 {
+		allowed := map[string]bool{}
+		for _, tag := range policy.AllowedTags {
+			allowed[tag] = true
+		}
+		for _, tag := range tags {
+			if !allowed[tag] {
+				errs.Append(errors.E(ErrStackPolicy, tagRange(tag),
+					fmt.Sprintf("stack %s has the tag %q which is not in terramate.config.stacks.allowed_tags",
+						dir, tag)))
+			}
+		}
+	}
+
+	hasTag := map[string]bool{}
+	for _, tag := range tags {
+		hasTag[tag] = true
+	}
+	for _, required := range policy.RequiredTags {
+		if !hasTag[required] {
+			errs.Append(errors.E(ErrStackPolicy, attrRange("tags"),
+				fmt.Sprintf("stack %s is missing the tag %q required by terramate.config.stacks.required_tags",
+					dir, required)))
+		}
+	}
+
+	name := stack.Name
+	if name == "" {
+		name = filepath.Base(tree.HostDir())
+	}
+	if policy.NamePattern != nil && !policy.NamePattern.MatchString(name) {
+		errs.Append(errors.E(ErrStackPolicy, attrRange("name"),
+			fmt.Sprintf("stack %s has the name %q which doesn't match terramate.config.stacks.name_pattern %q",
+				dir, name, policy.NamePattern.String())))
+	}
+
+	if policy.RequireID && stack.ID == "" {
+		errs.Append(errors.E(ErrStackPolicy, attrRange("id"),
+			fmt.Sprintf("stack %s has no ID but terramate.config.stacks.require_id is set", dir)))
+	}
+	return errs.AsError()
+}
@@ -228,6 +228,14 @@ export default defineConfig({
                 text: 'tm_version_match',
                 link: 'functions/terramate-builtin/tm_version_match.md',
               },
+              {
+                text: 'tm_sensitive',
+                link: 'functions/terramate-builtin/tm_sensitive.md',
+              },
+              {
+                text: 'tm_nonsensitive',
+                link: 'functions/terramate-builtin/tm_nonsensitive.md',
+              },
               {
                 text: 'Experimental Functions',
                 items: [
@@ -251,6 +259,7 @@ export default defineConfig({
         collapsed: false,
         items: [
           { text: 'Overview', link: 'cmdline/index'},
+          { text: 'affected', link: 'cmdline/affected' },
           { text: 'clone', link: 'cmdline/clone' },
           { text: 'cloud login', link: 'cmdline/cloud-login' },
           { text: 'cloud info', link: 'cmdline/cloud-info' },
@@ -270,6 +279,7 @@ export default defineConfig({
           { text: 'run', link: 'cmdline/run' },
           { text: 'trigger', link: 'cmdline/trigger' },
           { text: 'vendor download', link: 'cmdline/vendor-download' },
+          { text: 'vendor imports', link: 'cmdline/vendor-imports' },
           { text: 'version', link: 'cmdline/version' },
         ],
       },
@@ -46,7 +46,7 @@ func ListTerramateFiles(dir string) ([]string, error) {
 		}
 
 		filename := dirEntry.Name()
-		if isTerramateFile(filename) {
+		if IsTerramateFile(filename) {
 			logger.Trace().Msg("Found Terramate file")
 			files = append(files, filename)
 		}
@@ -95,6 +95,14 @@ func ListTerramateDirs(dir string) ([]string, error) {
 	return dirs, nil
 }
 
-func isTerramateFile(filename string) bool {
-	return strings.HasSuffix(filename, ".tm") || strings.HasSuffix(filename, ".tm.hcl")
+// IsTerramateFile tells if the filename is a Terramate configuration file.
+func IsTerramateFile(filename string) bool {
+	return strings.HasSuffix(filename, ".tm") || strings.HasSuffix(filename, ".tm.hcl") ||
+		IsTerramateJSONFile(filename)
+}
+
+// IsTerramateJSONFile tells if the filename is a Terramate configuration file
+// written in the JSON syntax.
+func IsTerramateJSONFile(filename string) bool {
+	return strings.HasSuffix(filename, ".tm.json")
 }
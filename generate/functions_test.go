@@ -0,0 +1,106 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/project"
+
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestGenerateUserFunctions(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "generate blocks calling user functions",
+			layout: []string{
+				"s:stacks/app",
+				"s:stacks/db",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: Doc(
+						Function(
+							Labels("name"),
+							Expr("params", "[app, env]"),
+							Expr("result", `"${app}-${env}"`),
+						),
+						Function(
+							Labels("subnet"),
+							Expr("params", "[index]"),
+							Expr("result", `tm_cidrsubnet("10.0.0.0/16", 8, index)`),
+						),
+						Globals(
+							Str("env", "prod"),
+						),
+					),
+				},
+				{
+					path: "/stacks/db",
+					add: Function(
+						Labels("name"),
+						Expr("params", "[app, env]"),
+						Expr("result", `"${env}-${app}-db"`),
+					),
+				},
+				{
+					path: "/stacks",
+					add: Doc(
+						GenerateHCL(
+							Labels("names.hcl"),
+							Content(
+								Expr("name", `tm_fn_name(terramate.stack.name, global.env)`),
+								Expr("subnet", `tm_fn_subnet(1)`),
+							),
+						),
+						GenerateFile(
+							Labels("name.txt"),
+							Expr("content", `tm_fn_name(terramate.stack.name, global.env)`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stacks/app",
+					files: map[string]fmt.Stringer{
+						"names.hcl": Doc(
+							Str("name", "app-prod"),
+							Str("subnet", "10.0.1.0/24"),
+						),
+						"name.txt": stringer("app-prod"),
+					},
+				},
+				{
+					dir: "/stacks/db",
+					files: map[string]fmt.Stringer{
+						"names.hcl": Doc(
+							Str("name", "prod-db-db"),
+							Str("subnet", "10.0.1.0/24"),
+						),
+						"name.txt": stringer("prod-db-db"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stacks/app"),
+						Created: []string{"name.txt", "names.hcl"},
+					},
+					{
+						Dir:     project.NewPath("/stacks/db"),
+						Created: []string{"name.txt", "names.hcl"},
+					},
+				},
+			},
+		},
+	})
+}
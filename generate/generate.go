@@ -12,6 +12,8 @@ import (
 	"sort"
 	"strings"
 
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
@@ -21,6 +23,7 @@ import (
 	"github.com/terramate-io/terramate/generate/genhcl"
 	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
 	"github.com/terramate-io/terramate/project"
@@ -98,7 +101,7 @@ func Load(root *config.Root, vendorDir project.Path) ([]LoadResult, error) {
 
 	for i, st := range stacks {
 		res := LoadResult{Dir: st.Dir()}
-		loadres := globals.ForStack(root, st.Stack)
+		loadres := loadStackGlobals(root, st.Stack)
 		if err := loadres.AsError(); err != nil {
 			res.Err = err
 			results[i] = res
@@ -304,8 +307,6 @@ func doRootGeneration(root *config.Root) Report {
 		Logger()
 
 	report := Report{}
-	evalctx := eval.NewContext(stdlib.Functions(root.HostDir()))
-	evalctx.SetNamespace("terramate", root.Runtime())
 
 	var files []GenFile
 	for _, cfg := range root.Tree().AsList() {
@@ -325,6 +326,8 @@ func doRootGeneration(root *config.Root) Report {
 			continue
 		}
 
+		evalctx := root.NewEvalContext(cfg.Dir(), stdlib.Functions(root.HostDir()))
+
 		for _, block := range blocks {
 			logger := genFileBlockLogger(logger, block)
 
@@ -435,6 +438,10 @@ processSubdirs:
 		absSubdir := filepath.Join(dir, relSubdir)
 		entries, err := os.ReadDir(absSubdir)
 		if err != nil {
+			if relSubdir == "" && errors.Is(err, fs.ErrNotExist) {
+				// eg.: a stack matrix combination not generated yet.
+				return genfiles, nil
+			}
 			return nil, errors.E(err)
 		}
 
@@ -557,7 +564,7 @@ func stackOutdated(
 		Stringer("stack", st).
 		Logger()
 
-	report := globals.ForStack(root, st)
+	report := loadStackGlobals(root, st)
 	if err := report.AsError(); err != nil {
 		return nil, errors.E(err, "checking for outdated code")
 	}
@@ -799,7 +806,7 @@ func forEachStack(
 
 		logger.Trace().Msg("Load stack globals.")
 
-		globalsReport := globals.ForStack(root, elem.Stack)
+		globalsReport := loadStackGlobals(root, elem.Stack)
 		if err := globalsReport.AsError(); err != nil {
 			report.addFailure(elem.Dir(), errors.E(ErrLoadingGlobals, err))
 			continue
@@ -1228,6 +1235,63 @@ func loadAsserts(root *config.Root, st *config.Stack, globals *eval.Object) ([]c
 	return asserts, nil
 }
 
+// loadStackGlobals evaluates only the globals of the stack referenced by the
+// generate blocks and the asserts of the stack directory and its parents, so
+// globals not used for code generation don't fail it.
+func loadStackGlobals(root *config.Root, st *config.Stack) globals.EvalReport {
+	var nodes []hclsyntax.Node
+	addExprs := func(exprs ...hhcl.Expression) {
+		for _, expr := range exprs {
+			if node, ok := expr.(hclsyntax.Node); ok {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	addAsserts := func(asserts []hcl.AssertConfig) {
+		for _, assert := range asserts {
+			addExprs(assert.Assertion, assert.Message, assert.Warning)
+		}
+	}
+	addLets := func(lets *ast.MergedBlock) {
+		if lets == nil {
+			return
+		}
+		for _, block := range lets.RawOrigins {
+			nodes = append(nodes, block.Block)
+		}
+	}
+
+	for dir := st.Dir; ; dir = dir.Dir() {
+		if cfg, ok := root.Lookup(dir); ok {
+			addAsserts(cfg.Node.Asserts)
+			for _, block := range cfg.Node.Generate.HCLs {
+				addLets(block.Lets)
+				addAsserts(block.Asserts)
+				if block.Content != nil {
+					nodes = append(nodes, block.Content)
+				}
+				if block.Condition != nil {
+					nodes = append(nodes, block.Condition)
+				}
+			}
+			for _, block := range cfg.Node.Generate.Files {
+				addLets(block.Lets)
+				addAsserts(block.Asserts)
+				if block.Content != nil {
+					nodes = append(nodes, block.Content)
+				}
+				if block.Condition != nil {
+					nodes = append(nodes, block.Condition)
+				}
+			}
+		}
+		if dir.Dir() == dir {
+			break
+		}
+	}
+	return globals.ForStackOnly(root, st, globals.Traversals(nodes...))
+}
+
 func loadStackCodeCfgs(
 	root *config.Root,
 	st *config.Stack,
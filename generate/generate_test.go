@@ -582,6 +582,145 @@ func TestGenerateCleanupFailsToReadFiles(t *testing.T) {
 	assert.Error(t, report.CleanupErr)
 }
 
+func TestGenerateStackMatrix(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "code is generated for each matrix combination",
+			layout: []string{
+				"d:infra",
+			},
+			configs: []hclconfig{
+				{
+					path: "/infra",
+					add: Doc(
+						Stack(
+							Block("matrix",
+								Expr("env", `["dev", "prd"]`),
+							),
+						),
+						Globals(
+							Expr("bucket", `"bucket-${each.env}"`),
+						),
+						GenerateFile(
+							Labels("env.txt"),
+							Expr("content", `"${terramate.stack.name}:${global.bucket}"`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/infra/dev",
+					files: map[string]fmt.Stringer{
+						"env.txt": stringer("infra-dev:bucket-dev"),
+					},
+				},
+				{
+					dir: "/infra/prd",
+					files: map[string]fmt.Stringer{
+						"env.txt": stringer("infra-prd:bucket-prd"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/infra/dev"),
+						Created: []string{"env.txt"},
+					},
+					{
+						Dir:     project.NewPath("/infra/prd"),
+						Created: []string{"env.txt"},
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestGenerateFromJSONConfig(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "json config has the same semantics as native config",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path:     "/",
+					filename: "globals.tm.json",
+					add: stringer(`{
+					  "globals": {
+					    "bucket": "bucket-${terramate.stack.name}",
+					    "tags": {"team": "infra"}
+					  }
+					}`),
+				},
+				{
+					path:     "/stack",
+					filename: "gen.tm.json",
+					add: stringer(`{
+					  "generate_hcl": {
+					    "main.tf": {
+					      "content": {
+					        "resource": {
+					          "aws_s3_bucket": {
+					            "b": [{
+					              "bucket": "${global.bucket}",
+					              "tags": "${global.tags}",
+					              "labels": {"name": "${global.bucket}"},
+					              "lifecycle": [{"prevent_destroy": true}]
+					            }]
+					          }
+					        }
+					      }
+					    }
+					  },
+					  "generate_file": {
+					    "name.txt": {"content": "${global.bucket}"}
+					  }
+					}`),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/stack",
+					files: map[string]fmt.Stringer{
+						"main.tf": Doc(
+							Block("resource",
+								Labels("aws_s3_bucket", "b"),
+								Str("bucket", "bucket-stack"),
+								Expr("labels", `{
+								  "name" = "bucket-stack"
+								}`),
+								Expr("tags", `{
+								  team = "infra"
+								}`),
+								Block("lifecycle",
+									Bool("prevent_destroy", true),
+								),
+							),
+						),
+						"name.txt": stringer("bucket-stack"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/stack"),
+						Created: []string{"main.tf", "name.txt"},
+					},
+				},
+			},
+		},
+	})
+}
+
 func TestGenerateConflictsBetweenGenerateTypes(t *testing.T) {
 	t.Parallel()
 
@@ -144,6 +144,13 @@ func Load(
 
 		evalctx.SetFunction(stdlib.Name("vendor"), stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests))
 
+		err := evalctx.SetStacksNamespace(
+			genFileBlock.Content, genFileBlock.Lets, genFileBlock.Condition, genFileBlock.Asserts,
+		)
+		if err != nil {
+			return nil, err
+		}
+
 		file, err := Eval(genFileBlock, evalctx.Context)
 		if err != nil {
 			return nil, err
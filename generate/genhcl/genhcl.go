@@ -168,7 +168,14 @@ func Load(
 			stdlib.VendorFunc(vendorTargetDir, vendorDir, vendorRequests),
 		)
 
-		err := lets.Load(hclBlock.Lets, evalctx.Context)
+		err := evalctx.SetStacksNamespace(
+			hclBlock.Content, hclBlock.Lets, hclBlock.Condition, hclBlock.Asserts,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		err = lets.Load(hclBlock.Lets, evalctx.Context)
 		if err != nil {
 			return nil, err
 		}
@@ -807,6 +807,10 @@ func TestGenerateHCL(t *testing.T) {
 							Expr("stack_path_basename", "terramate.stack.path.basename"),
 							Expr("stack_path_rel", "terramate.stack.path.relative"),
 							Expr("stack_path_to_root", "terramate.stack.path.to_root"),
+							Expr("stack_parent", "terramate.stack.parent"),
+							Expr("stack_children", "terramate.stack.children"),
+							Expr("stack_dependencies", "terramate.stack.dependencies"),
+							Expr("stack_watch", "terramate.stack.watch"),
 						),
 					),
 				},
@@ -817,13 +821,22 @@ func TestGenerateHCL(t *testing.T) {
 					hcl: genHCL{
 						condition: true,
 						body: Doc(
+							EvalExpr(t, "stack_children", `[]`),
+							EvalExpr(t, "stack_dependencies", `{
+							  after     = []
+							  before    = []
+							  wanted_by = []
+							  wants     = []
+							}`),
 							Str("stack_description", ""),
 							Str("stack_id", "no-id"),
 							Str("stack_name", "stack"),
+							Str("stack_parent", ""),
 							Str("stack_path_abs", "/stacks/stack"),
 							Str("stack_path_basename", "stack"),
 							Str("stack_path_rel", "stacks/stack"),
 							Str("stack_path_to_root", "../.."),
+							EvalExpr(t, "stack_watch", `[]`),
 							EvalExpr(t, "stacks_list", `["/stacks/stack"]`),
 						),
 					},
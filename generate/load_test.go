@@ -397,7 +397,7 @@ func TestLoad(t *testing.T) {
 						),
 						GenerateFile(
 							Labels("test.txt"),
-							Str("content", "test"),
+							Expr("content", `tm_try(global.a, "test")`),
 						),
 					),
 				},
@@ -445,7 +445,44 @@ func TestLoad(t *testing.T) {
 							blockRange: Range(
 								"/config.tm",
 								Start(6, 1, 64),
-								End(8, 2, 111),
+								End(8, 2, 129),
+							),
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "failing globals not referenced by generate blocks",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []file{
+				{
+					path: "config.tm",
+					body: Doc(
+						Globals(
+							Str("a", "test"),
+							Expr("bad", "tm_upper(1, 2)"),
+						),
+						GenerateFile(
+							Labels("test.txt"),
+							Expr("content", "global.a"),
+						),
+					),
+				},
+			},
+			want: []result{
+				{
+					dir: "/stack",
+					files: []genfile{
+						{
+							label:     "test.txt",
+							condition: true,
+							blockRange: Range(
+								"/config.tm",
+								Start(5, 1, 50),
+								End(7, 2, 99),
 							),
 						},
 					},
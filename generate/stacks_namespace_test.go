@@ -0,0 +1,101 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package generate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/generate"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+
+	. "github.com/terramate-io/terramate/test/hclwrite/hclutils"
+)
+
+func TestGenerateStacksNamespace(t *testing.T) {
+	t.Parallel()
+
+	testCodeGeneration(t, []testcase{
+		{
+			name: "generate_hcl and generate_file referencing other stacks",
+			layout: []string{
+				"s:network:id=network-id",
+				"s:app",
+			},
+			configs: []hclconfig{
+				{
+					path: "/network",
+					add: Globals(
+						Str("state_key", "network/terraform.tfstate"),
+					),
+				},
+				{
+					path: "/app",
+					add: Doc(
+						GenerateHCL(
+							Labels("remote.hcl"),
+							Content(
+								Expr("key", `stacks.by_path["/network"].global.state_key`),
+								Expr("name", `stacks.by_id["network-id"].stack.name`),
+							),
+						),
+						GenerateFile(
+							Labels("key.txt"),
+							Expr("content", `stacks.by_path["/network"].global.state_key`),
+						),
+					),
+				},
+			},
+			want: []generatedFile{
+				{
+					dir: "/app",
+					files: map[string]fmt.Stringer{
+						"remote.hcl": Doc(
+							Str("key", "network/terraform.tfstate"),
+							Str("name", "network"),
+						),
+						"key.txt": stringer("network/terraform.tfstate"),
+					},
+				},
+			},
+			wantReport: generate.Report{
+				Successes: []generate.Result{
+					{
+						Dir:     project.NewPath("/app"),
+						Created: []string{"key.txt", "remote.hcl"},
+					},
+				},
+			},
+		},
+		{
+			name: "generate_hcl referencing unknown stack",
+			layout: []string{
+				"s:app",
+			},
+			configs: []hclconfig{
+				{
+					path: "/app",
+					add: GenerateHCL(
+						Labels("remote.hcl"),
+						Content(
+							Expr("key", `stacks.by_path["/network"].global.state_key`),
+						),
+					),
+				},
+			},
+			wantReport: generate.Report{
+				Failures: []generate.FailureResult{
+					{
+						Result: generate.Result{
+							Dir: project.NewPath("/app"),
+						},
+						Error: errors.E(globals.ErrStackRef),
+					},
+				},
+			},
+		},
+	})
+}
@@ -743,6 +743,25 @@ func (git *Git) ListUncommitted(dirs ...string) ([]string, error) {
 	return removeEmptyLines(strings.Split(out, "\n")), nil
 }
 
+// ListChangedFrom lists the tracked files whose content in the index or in the
+// working tree differs from the rev commit, which includes the staged files.
+func (git *Git) ListChangedFrom(rev string) ([]string, error) {
+	out, err := git.exec("diff-index", "--name-only", rev, "--")
+	if err != nil {
+		return nil, fmt.Errorf("diff-index: %w", err)
+	}
+	return removeEmptyLines(strings.Split(out, "\n")), nil
+}
+
+// LastCommitTouching returns the ID of the last commit reachable from HEAD
+// which changed any file inside the given paths, or an empty string if no
+// commit touched them.
+func (git *Git) LastCommitTouching(paths ...string) (string, error) {
+	args := []string{"-n", "1", "HEAD", "--"}
+	args = append(args, paths...)
+	return git.exec("rev-list", args...)
+}
+
 // ShowCommitMetadata returns common metadata associated with the given object.
 // An object name can be a commit SHA or a symbolic name, i.e. HEAD, branch-name, etc.
 func (git *Git) ShowCommitMetadata(objectName string) (*CommitMetadata, error) {
@@ -796,6 +815,23 @@ func (git *Git) Root() (string, error) {
 	return git.exec("rev-parse", "--show-toplevel")
 }
 
+// LsTreeFiles returns the given files which exist in the tree of the rev
+// commit. The paths are relative to the working dir.
+func (git *Git) LsTreeFiles(rev string, paths ...string) ([]string, error) {
+	args := append([]string{"--name-only", rev, "--"}, paths...)
+	out, err := git.exec("ls-tree", args...)
+	if err != nil {
+		return nil, err
+	}
+	return removeEmptyLines(strings.Split(out, "\n")), nil
+}
+
+// ShowFile returns the content of the file in the tree of the rev commit.
+// The path is relative to the working dir.
+func (git *Git) ShowFile(rev string, path string) (string, error) {
+	return git.exec("show", rev+":./"+path)
+}
+
 // IsRepository tell if the git wrapper setup is operating in a valid git
 // repository.
 func (git *Git) IsRepository() bool {
@@ -0,0 +1,105 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+)
+
+// allGlobals is the traversal referencing the whole global namespace.
+var allGlobals = []hhcl.Traversal{{hhcl.TraverseRoot{Name: "global"}}}
+
+// EvalOnly evaluates only the globals referenced by the given traversals and
+// the globals they depend on, directly or transitively. Traversals of other
+// namespaces are ignored. The returned report only has the globals needed by
+// the traversals and only their errors, then failing globals not referenced
+// don't affect the result.
+func (dirExprs HierarchicalExprs) EvalOnly(ctx *eval.Context, traversals []hhcl.Traversal) EvalReport {
+	return dirExprs.only(traversals).Eval(ctx)
+}
+
+// only returns the expressions needed to evaluate the globals referenced by
+// the traversals: the expressions defining the referenced paths, their parent
+// and nested paths, and recursively the expressions they reference. The global
+// schemas of the selected globals are kept and their validations are also
+// considered references.
+func (dirExprs HierarchicalExprs) only(traversals []hhcl.Traversal) HierarchicalExprs {
+	exprs := dirExprs.effective()
+	schemas := dirExprs.schemas()
+
+	selected := map[GlobalPathKey]struct{}{}
+	selectedSchemas := map[string]struct{}{}
+
+	var pending [][]string
+	push := func(traversals []hhcl.Traversal) bool {
+		for _, traversal := range traversals {
+			if traversal.RootName() != "global" {
+				continue
+			}
+			if len(traversal) == 1 {
+				return false
+			}
+			pending = append(pending, globalTraversalPath(traversal))
+		}
+		return true
+	}
+
+	if !push(traversals) {
+		return dirExprs
+	}
+	for len(pending) > 0 {
+		path := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+
+		for key, expr := range exprs {
+			if _, ok := selected[key]; ok || !globalPathsOverlap(key.Path(), path) {
+				continue
+			}
+			selected[key] = struct{}{}
+			pending = append(pending, key.Path())
+			if !push(expr.Variables()) {
+				return dirExprs
+			}
+		}
+		for _, schema := range schemas {
+			if _, ok := selectedSchemas[schema.Name()]; ok || !globalPathsOverlap(schema.Path, path) {
+				continue
+			}
+			selectedSchemas[schema.Name()] = struct{}{}
+			if !push(schemaTraversals(schema)) {
+				return dirExprs
+			}
+		}
+	}
+
+	res := make(HierarchicalExprs, len(dirExprs))
+	for dir, exprset := range dirExprs {
+		filtered := newExprSet(exprset.origin)
+		for key, expr := range exprset.expressions {
+			if _, ok := selected[key]; ok {
+				filtered.expressions[key] = expr
+			}
+		}
+		for name, schema := range exprset.schemas {
+			if _, ok := selectedSchemas[name]; ok {
+				filtered.schemas[name] = schema
+			}
+		}
+		res[dir] = filtered
+	}
+	return res
+}
+
+// schemaTraversals returns the traversals referenced by the validations of
+// the schema.
+func schemaTraversals(schema hcl.GlobalSchemaConfig) []hhcl.Traversal {
+	var traversals []hhcl.Traversal
+	for _, validation := range schema.Validations {
+		traversals = append(traversals, validation.Condition.Variables()...)
+		traversals = append(traversals, validation.ErrorMessage.Variables()...)
+	}
+	return traversals
+}
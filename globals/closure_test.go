@@ -0,0 +1,152 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGlobalsEvalOnly(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		exprs      []string
+		want       map[string]cty.Value
+		wantErrors []string
+	}
+
+	const rootGlobals = `
+	  globals {
+	    env    = "prod"
+	    region = "eu-west-1"
+	    name   = "${global.env}-${global.region}"
+	    broken = tm_upper(global.undefined)
+	    uses_broken = "${global.broken}!"
+	  }
+
+	  globals "network" {
+	    cidr = "10.0.0.0/16"
+	  }
+
+	  globals "network" "subnets" {
+	    private = tm_cidrsubnet(global.network.cidr, 8, 1)
+	  }
+
+	  global_schema "region" {
+	    type = string
+	    validation {
+	      condition     = global.region != global.forbidden_region
+	      error_message = "region not allowed"
+	    }
+	  }
+
+	  global_schema "required" {
+	    required = true
+	  }
+	`
+
+	const stackGlobals = `
+	  globals {
+	    forbidden_region = "us-east-1"
+	    unrelated        = "unrelated"
+	  }
+	`
+
+	for _, tc := range []testcase{
+		{
+			name:  "no globals referenced",
+			exprs: []string{`terramate.stack.path.absolute`},
+			want:  map[string]cty.Value{},
+		},
+		{
+			name:  "transitive dependencies",
+			exprs: []string{`global.name`},
+			want: map[string]cty.Value{
+				"env":    cty.StringVal("prod"),
+				"region": cty.StringVal("eu-west-1"),
+				"name":   cty.StringVal("prod-eu-west-1"),
+				// referenced by the validation of the region schema.
+				"forbidden_region": cty.StringVal("us-east-1"),
+			},
+		},
+		{
+			name:  "nested object and its dependencies",
+			exprs: []string{`global.network.subnets`},
+			want: map[string]cty.Value{
+				"network": cty.ObjectVal(map[string]cty.Value{
+					"cidr": cty.StringVal("10.0.0.0/16"),
+					"subnets": cty.ObjectVal(map[string]cty.Value{
+						"private": cty.StringVal("10.0.1.0/24"),
+					}),
+				}),
+			},
+		},
+		{
+			name:  "only the referenced errors",
+			exprs: []string{`"${global.uses_broken} ${global.unrelated}"`},
+			want: map[string]cty.Value{
+				"unrelated": cty.StringVal("unrelated"),
+			},
+			wantErrors: []string{"broken", "uses_broken"},
+		},
+		{
+			name:       "schema of referenced global",
+			exprs:      []string{`global.required`},
+			want:       map[string]cty.Value{},
+			wantErrors: []string{"required"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{
+				"s:stack",
+				"f:globals.tm:" + rootGlobals,
+				"f:stack/globals.tm:" + stackGlobals,
+			})
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+			st, err := config.LoadStack(root, project.NewPath("/stack"))
+			assert.NoError(t, err)
+
+			var traversals []hhcl.Traversal
+			for _, exprStr := range tc.exprs {
+				traversals = append(traversals, test.NewExpr(t, exprStr).Variables()...)
+			}
+			report := globals.ForStackOnly(root, st, traversals)
+			assert.NoError(t, report.BootstrapErr)
+
+			got := report.Globals.AsValueMap()
+			if diff := cmp.Diff(tc.want, got, cmp.Comparer(func(a, b cty.Value) bool {
+				return a.RawEquals(b)
+			})); diff != "" {
+				t.Fatalf("unexpected globals: %s", diff)
+			}
+
+			var gotErrors []string
+			for key := range report.Errors {
+				gotErrors = append(gotErrors, key.Path()[0])
+			}
+			sort.Strings(gotErrors)
+			if diff := cmp.Diff(tc.wantErrors, gotErrors); diff != "" {
+				t.Fatalf("unexpected errors: %s", diff)
+			}
+		})
+	}
+}
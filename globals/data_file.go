@@ -0,0 +1,137 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty-yaml"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// ErrDataFile indicates that a globals_file data file failed to load.
+const ErrDataFile errors.Kind = "loading globals data file"
+
+// DataFiles returns the data files loaded as globals by the configuration of
+// the tree directory and of its parent directories, then a change in any of
+// them changes the globals of the directory.
+func DataFiles(tree *config.Tree) []project.Path {
+	var files []project.Path
+	for ; tree != nil; tree = tree.Parent {
+		for _, globalsFile := range tree.Node.GlobalsFiles {
+			files = append(files, globalsFile.Path)
+		}
+	}
+	return files
+}
+
+// loadDataFile loads the globals_file data file as global expressions of the
+// tree directory.
+func loadDataFile(tree *config.Tree, globalsFile hcl.GlobalsFileConfig, exprs *ExprSet) error {
+	val, err := decodeDataFile(tree.RootDir(), globalsFile.Path)
+	if err != nil {
+		return errors.E(ErrDataFile, globalsFile.Range, err)
+	}
+
+	newExpr := func(labelPath []string, val cty.Value) Expr {
+		return Expr{
+			Origin:    globalsFile.Range,
+			ConfigDir: tree.Dir(),
+			LabelPath: labelPath,
+			Expression: &hclsyntax.LiteralValueExpr{
+				Val:      val,
+				SrcRange: globalsFile.Range.ToHCLRange(),
+			},
+		}
+	}
+
+	labels := globalsFile.Labels
+	if !val.Type().IsObjectType() && !val.Type().IsMapType() {
+		if len(labels) == 0 {
+			return errors.E(ErrDataFile, globalsFile.Range,
+				"data file %s must contain an object to be loaded without labels",
+				globalsFile.Path)
+		}
+		key := NewGlobalAttrPath(labels[:len(labels)-1], labels[len(labels)-1])
+		exprs.expressions[key] = newExpr(key.Path(), val)
+		return nil
+	}
+
+	if len(labels) > 0 {
+		key := NewGlobalExtendPath(labels)
+		exprs.expressions[key] = newExpr(key.Path(), cty.EmptyObjectVal)
+	}
+	for name, attrVal := range val.AsValueMap() {
+		if !hclsyntax.ValidIdentifier(name) {
+			return errors.E(ErrDataFile, globalsFile.Range,
+				"data file %s has the key %q which is not a valid global name",
+				globalsFile.Path, name)
+		}
+		key := NewGlobalAttrPath(labels, name)
+		exprs.expressions[key] = newExpr(key.Path(), attrVal)
+	}
+	return nil
+}
+
+func decodeDataFile(rootdir string, file project.Path) (cty.Value, error) {
+	abspath := filepath.Join(rootdir, filepath.FromSlash(file.String()))
+	data, err := os.ReadFile(abspath)
+	if err != nil {
+		return cty.NilVal, errors.E(err, "reading data file %s", file)
+	}
+
+	switch ext := strings.ToLower(path.Ext(file.String())); ext {
+	case ".json":
+		return decodeJSONData(file, "JSON", data)
+	case ".toml":
+		var obj map[string]interface{}
+		if err := toml.Unmarshal(data, &obj); err != nil {
+			return cty.NilVal, errors.E(err, "parsing TOML data file %s", file)
+		}
+		// TOML values have JSON equivalents, where dates and times are
+		// represented as RFC 3339 strings.
+		data, err := json.Marshal(obj)
+		if err != nil {
+			return cty.NilVal, errors.E(err, "converting TOML data file %s", file)
+		}
+		return decodeJSONData(file, "TOML", data)
+	case ".yaml", ".yml":
+		typ, err := yaml.ImpliedType(data)
+		if err != nil {
+			return cty.NilVal, errors.E(err, "parsing YAML data file %s", file)
+		}
+		val, err := yaml.Unmarshal(data, typ)
+		if err != nil {
+			return cty.NilVal, errors.E(err, "parsing YAML data file %s", file)
+		}
+		return val, nil
+	default:
+		return cty.NilVal, errors.E(
+			"data file %s has the unsupported extension %q: supported extensions are .json, .yaml, .yml and .toml",
+			file, ext)
+	}
+}
+
+func decodeJSONData(file project.Path, format string, data []byte) (cty.Value, error) {
+	typ, err := ctyjson.ImpliedType(data)
+	if err != nil {
+		return cty.NilVal, errors.E(err, "parsing %s data file %s", format, file)
+	}
+	val, err := ctyjson.Unmarshal(data, typ)
+	if err != nil {
+		return cty.NilVal, errors.E(err, "parsing %s data file %s", format, file)
+	}
+	return val, nil
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsFromDataFiles(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/stack",
+		`f:data/common.yaml:region: us-east-1
+env: dev
+tags:
+  - a
+  - b
+`,
+		`f:data/network.json:{"cidr": "10.0.0.0/16", "subnets": 3}`,
+		`f:data/app.toml:name = "app"
+replicas = 2
+
+[limits]
+cpu = "500m"
+`,
+		`f:globals.tm:globals_file {
+		  path = "/data/common.yaml"
+		}
+		globals {
+		  env = "prod"
+		}`,
+		`f:stacks/globals.tm:globals_file "net" {
+		  path = "../data/network.json"
+		}`,
+		`f:stacks/stack/globals.tm:globals "net" {
+		  subnets = 6
+		}
+		globals_file "app" {
+		  path = "/data/app.toml"
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stacks/stack"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	assert.NoError(t, report.AsError())
+
+	got := report.Globals.AsValueMap()
+	assert.EqualStrings(t, "us-east-1", got["region"].AsString())
+	assert.EqualStrings(t, "prod", got["env"].AsString())
+	assert.EqualInts(t, 2, got["tags"].LengthInt())
+
+	net := got["net"].AsValueMap()
+	assert.EqualStrings(t, "10.0.0.0/16", net["cidr"].AsString())
+	assert.IsTrue(t, net["subnets"].AsBigFloat().String() == "6")
+
+	app := got["app"].AsValueMap()
+	assert.EqualStrings(t, "app", app["name"].AsString())
+	assert.IsTrue(t, app["replicas"].AsBigFloat().String() == "2")
+	assert.EqualStrings(t, "500m", app["limits"].GetAttr("cpu").AsString())
+
+	tree, ok := root.Lookup(st.Dir)
+	assert.IsTrue(t, ok)
+	files := globals.DataFiles(tree)
+	assert.EqualInts(t, 3, len(files))
+	assert.EqualStrings(t, "/data/app.toml", files[0].String())
+	assert.EqualStrings(t, "/data/network.json", files[1].String())
+	assert.EqualStrings(t, "/data/common.yaml", files[2].String())
+}
+
+func TestGlobalsFromDataFilesErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name string
+		data string
+		file string
+	}{
+		{
+			name: "unsupported extension",
+			file: "data.ini",
+			data: `a = 1`,
+		},
+		{
+			name: "invalid toml",
+			file: "data.toml",
+			data: `a = `,
+		},
+		{
+			name: "invalid yaml",
+			file: "data.yaml",
+			data: "a: [1, 2",
+		},
+		{
+			name: "non object without labels",
+			file: "data.json",
+			data: `[1, 2, 3]`,
+		},
+		{
+			name: "invalid global name",
+			file: "data.json",
+			data: `{"not.valid": 1}`,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree([]string{
+				"s:stack",
+				"f:" + tc.file + ":" + tc.data,
+				`f:globals.tm:globals_file {
+				  path = "/` + tc.file + `"
+				}`,
+			})
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+			st, err := config.LoadStack(root, project.NewPath("/stack"))
+			assert.NoError(t, err)
+
+			report := globals.ForStack(root, st)
+			assert.IsError(t, report.AsError(), errors.E(globals.ErrDataFile))
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	. "github.com/terramate-io/terramate/test/hclutils"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsUserFunctions(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-b",
+		`f:functions.tm:function "prefix" {
+		  params = [name]
+		  result = "prod-${name}"
+		}
+		function "bucket" {
+		  params = [name, region]
+		  result = tm_lower("${tm_fn_prefix(name)}-${region}")
+		}`,
+		`f:globals.tm:globals {
+		  bucket = tm_fn_bucket("Logs", "EU")
+		}`,
+		`f:stack-b/functions.tm:function "prefix" {
+		  params = [name]
+		  result = "dev-${name}"
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	for dir, want := range map[string]string{
+		"/stack-a": "prod-logs-eu",
+		"/stack-b": "dev-logs-eu",
+	} {
+		st, err := config.LoadStack(root, project.NewPath(dir))
+		assert.NoError(t, err)
+		report := globals.ForStack(root, st)
+		assert.NoError(t, report.AsError())
+		got := report.Globals.AsValueMap()["bucket"]
+		assert.EqualStrings(t, want, got.AsString(), "stack %s", dir)
+	}
+}
+
+func TestGlobalsUserFunctionsRecursion(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:functions.tm:function "a" {
+		  params = [n]
+		  result = tm_fn_b(n)
+		}
+		function "b" {
+		  params = [n]
+		  result = tm_fn_a(n)
+		}`,
+		`f:stack/globals.tm:globals {
+		  val = tm_fn_a(1)
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	err = report.AsError()
+	if err == nil {
+		t.Fatal("recursive functions must fail")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		"tm_fn_a -> tm_fn_b -> tm_fn_a",
+		"stack/globals.tm",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q doesn't contain %q", msg, want)
+		}
+	}
+}
+
+func TestGlobalsUserFunctionsErrorsHaveCallRange(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:functions.tm:function "a" {
+  params = [n]
+  result = tm_fn_b(n)
+}
+function "b" {
+  params = [n]
+  result = n + "x"
+}`,
+		`f:stack/globals.tm:globals {
+  val = tm_fn_a(1)
+}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	err = report.AsError()
+	callRange := Mkrange(filepath.Join(s.RootDir(), "stack/globals.tm"),
+		Start(2, 9, 18), End(2, 17, 26))
+	if !errors.Is(err, errors.E(callRange)) {
+		t.Fatalf("error %v doesn't have the call range %s", err, callRange)
+	}
+
+	// the calls inside the functions are reported with their ranges too.
+	nestedCallRange := Mkrange(filepath.Join(s.RootDir(), "functions.tm"),
+		Start(3, 12, 40), End(3, 20, 48))
+	if !strings.Contains(err.Error(), nestedCallRange.String()) {
+		t.Fatalf("error %v doesn't have the nested call range %s", err, nestedCallRange)
+	}
+	if strings.Contains(err.Error(), "defined at") {
+		t.Fatalf("error %v must cite the calls instead of the function definitions", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsGitMetadata(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.New(t)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-b",
+		`f:globals.tm:globals {
+		  commit      = terramate.git.commit
+		  branch      = terramate.git.branch
+		  remote_url  = terramate.git.remote_url
+		  dirty       = terramate.git.dirty
+		  last_commit = terramate.stack.git.last_commit
+		}`,
+	})
+	git := s.Git()
+	git.CommitAll("add stacks")
+	stackACommit := git.RevParse("HEAD")
+
+	s.RootEntry().CreateFile("stack-b/main.tf", "# changed")
+	git.CommitAll("change stack-b")
+	head := git.RevParse("HEAD")
+
+	evalStack := func(dir string) map[string]string {
+		root, err := config.LoadRoot(s.RootDir())
+		assert.NoError(t, err)
+		st, err := config.LoadStack(root, project.NewPath(dir))
+		assert.NoError(t, err)
+		report := globals.ForStack(root, st)
+		assert.NoError(t, report.AsError())
+		got := map[string]string{}
+		for name, val := range report.Globals.AsValueMap() {
+			if name == "dirty" {
+				got[name] = strconv.FormatBool(val.True())
+				continue
+			}
+			got[name] = val.AsString()
+		}
+		return got
+	}
+
+	got := evalStack("/stack-a")
+	assert.EqualStrings(t, head, got["commit"])
+	assert.EqualStrings(t, "main", got["branch"])
+	assert.EqualStrings(t, git.BareRepoAbsPath(), got["remote_url"])
+	assert.EqualStrings(t, "false", got["dirty"])
+	assert.EqualStrings(t, stackACommit, got["last_commit"])
+
+	got = evalStack("/stack-b")
+	assert.EqualStrings(t, head, got["last_commit"])
+
+	s.RootEntry().CreateFile("stack-a/untracked.txt", "untracked")
+	got = evalStack("/stack-a")
+	assert.EqualStrings(t, "true", got["dirty"])
+}
+
+func TestGlobalsGitMetadataIsLazy(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:globals.tm:globals {
+		  name = terramate.stack.name
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	assert.NoError(t, report.AsError())
+
+	s.RootEntry().CreateFile("git.tm", `globals {
+	  commit = terramate.git.commit
+	}`)
+	root, err = config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	report = globals.ForStack(root, st)
+	assert.IsError(t, report.AsError(), errors.E(config.ErrGitMetadata))
+}
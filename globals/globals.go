@@ -94,6 +94,7 @@ func ForDir(root *config.Root, cfgdir project.Path, ctx *eval.Context) EvalRepor
 		return report
 	}
 
+	exprs.SetOverrides(cfgdir, root.GlobalOverrides())
 	return exprs.Eval(ctx)
 }
 
@@ -102,6 +103,7 @@ func ForDir(root *config.Root, cfgdir project.Path, ctx *eval.Context) EvalRepor
 type ExprSet struct {
 	origin      project.Path
 	expressions map[GlobalPathKey]Expr
+	schemas     map[string]hcl.GlobalSchemaConfig
 }
 
 // HierarchicalExprs contains all loaded global expressions from multiple
@@ -113,6 +115,7 @@ func newExprSet(origin project.Path) *ExprSet {
 	return &ExprSet{
 		origin:      origin,
 		expressions: map[GlobalPathKey]Expr{},
+		schemas:     map[string]hcl.GlobalSchemaConfig{},
 	}
 }
 
@@ -129,6 +132,16 @@ func LoadExprs(tree *config.Tree) (HierarchicalExprs, error) {
 
 	exprs := newExprSet(tree.Dir())
 
+	for _, globalsFile := range tree.Node.GlobalsFiles {
+		logger.Trace().
+			Stringer("file", globalsFile.Path).
+			Msg("Loading globals from data file.")
+
+		if err := loadDataFile(tree, globalsFile, exprs); err != nil {
+			return nil, err
+		}
+	}
+
 	globalsBlocks := tree.Node.Globals.AsList()
 	for _, block := range globalsBlocks {
 		if len(block.Labels) > 0 && !hclsyntax.ValidIdentifier(block.Labels[0]) {
@@ -190,6 +203,10 @@ func LoadExprs(tree *config.Tree) (HierarchicalExprs, error) {
 		}
 	}
 
+	for _, schema := range tree.Node.GlobalSchemas {
+		exprs.schemas[schema.Name()] = schema
+	}
+
 	globals := HierarchicalExprs{
 		tree.Dir(): exprs,
 	}
@@ -233,6 +250,32 @@ func (dirExprs HierarchicalExprs) SetOverride(
 	}
 }
 
+// effective returns the expressions of the globals, where the expressions of
+// the more specific directories override the ones of their parents.
+func (dirExprs HierarchicalExprs) effective() map[GlobalPathKey]Expr {
+	exprs := map[GlobalPathKey]Expr{}
+	for _, xp := range dirExprs.sort() {
+		for k, v := range xp.expressions {
+			exprs[k] = v
+		}
+	}
+	return exprs
+}
+
+// stacksTraversals returns the traversals of the stacks namespace used by the
+// effective globals expressions.
+func (dirExprs HierarchicalExprs) stacksTraversals() []hhcl.Traversal {
+	var traversals []hhcl.Traversal
+	for _, expr := range dirExprs.effective() {
+		for _, traversal := range expr.Variables() {
+			if traversal.RootName() == StacksNamespace {
+				traversals = append(traversals, traversal)
+			}
+		}
+	}
+	return traversals
+}
+
 // Returns a sorted loaded exprs, sorting it by config dir path.
 // The loaded expressions are sorted by the config dir path
 // from smaller (root) to more specific (stack). Eg:
@@ -275,7 +318,22 @@ func (dirExprs ExprSet) sort() []GlobalPathKey {
 }
 
 // Eval evaluates all global expressions and returns an EvalReport.
+// The global schemas are not checked, as they only apply to stacks.
 func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
+	return dirExprs.eval(ctx, nil, nil, false)
+}
+
+// eval evaluates all global expressions, reusing the values of the
+// expressions that don't depend on the stack from the cache, if not nil. The
+// funcs are the user functions available to the expressions, which are part
+// of the cached values identity. If validate is true, the evaluated globals
+// are checked against the global schemas.
+func (dirExprs HierarchicalExprs) eval(
+	ctx *eval.Context,
+	cache *config.Cache,
+	funcs map[string]hcl.FunctionConfig,
+	validate bool,
+) EvalReport {
 	logger := log.With().
 		Str("action", "HierarchicalExprs.Eval()").
 		Logger()
@@ -287,15 +345,16 @@ func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
 	pendingExprsErrs := map[GlobalPathKey]*errors.List{}
 
 	sortedLoadedExprs := dirExprs.sort()
-	pendingExprs := map[GlobalPathKey]Expr{}
+	pendingExprs := dirExprs.effective()
 
-	// Here we will override values, but since
-	// we ordered by config dir the more specific global expressions
-	// will override the parent ones.
-	for _, xp := range sortedLoadedExprs {
-		for k, v := range xp.expressions {
-			pendingExprs[k] = v
-		}
+	finalExprs := make(map[GlobalPathKey]Expr, len(pendingExprs))
+	for k, v := range pendingExprs {
+		finalExprs[k] = v
+	}
+
+	var memo *memoizer
+	if cache != nil {
+		memo = newMemoizer(cache, finalExprs, funcs)
 	}
 
 	// Here we will sort each set of globals from each dir independently
@@ -374,46 +433,9 @@ func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
 						continue
 					}
 
-					var varPaths []string
-
-					for _, ns := range namespace[1:] {
-						switch attr := ns.(type) {
-						case hhcl.TraverseAttr:
-							varPaths = append(varPaths, attr.Name)
-						case hhcl.TraverseSplat:
-							// ignore
-						case hhcl.TraverseIndex:
-							if !attr.Key.Type().Equals(cty.String) {
-								break
-							}
-
-							varPaths = append(varPaths, attr.Key.AsString())
-						default:
-							panic(errors.E(
-								errors.ErrInternal,
-								"unexpected type of traversal - this is a BUG: %T",
-								attr,
-							))
-						}
-					}
-
-					min := func(a, b int) int {
-						if a < b {
-							return a
-						}
-						return b
-					}
-
+					varPaths := globalTraversalPath(namespace)
 					for accessPath := range pendingExprs {
-						found := true
-						accessPathPaths := accessPath.Path()
-						for i := min(len(accessPathPaths), len(varPaths)) - 1; i >= 0; i-- {
-							if accessPathPaths[i] != varPaths[i] {
-								found = false
-								break
-							}
-						}
-						if found {
+						if globalPathsOverlap(accessPath.Path(), varPaths) {
 							continue pendingExpression
 						}
 					}
@@ -473,7 +495,7 @@ func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
 
 				logger.Trace().Msg("evaluating expression")
 
-				val, err := ctx.Eval(expr)
+				val, err := memo.eval(ctx, accessor, expr)
 				if err != nil {
 					pendingExprsErrs[accessor].Append(errors.E(
 						ErrEval, err, "global.%s (%t)", accessor.rootname(), accessor.isattr))
@@ -537,6 +559,9 @@ func (dirExprs HierarchicalExprs) Eval(ctx *eval.Context) EvalReport {
 		}
 	}
 
+	if validate {
+		dirExprs.validateSchemas(ctx, finalExprs, &report)
+	}
 	return report
 }
 
@@ -553,6 +578,48 @@ func (dirExprs HierarchicalExprs) merge(other HierarchicalExprs) {
 	}
 }
 
+// globalTraversalPath returns the global path accessed by the traversal of
+// the global namespace.
+func globalTraversalPath(traversal hhcl.Traversal) []string {
+	var varPaths []string
+	for _, ns := range traversal[1:] {
+		switch attr := ns.(type) {
+		case hhcl.TraverseAttr:
+			varPaths = append(varPaths, attr.Name)
+		case hhcl.TraverseSplat:
+			// ignore
+		case hhcl.TraverseIndex:
+			if !attr.Key.Type().Equals(cty.String) {
+				break
+			}
+
+			varPaths = append(varPaths, attr.Key.AsString())
+		default:
+			panic(errors.E(
+				errors.ErrInternal,
+				"unexpected type of traversal - this is a BUG: %T",
+				attr,
+			))
+		}
+	}
+	return varPaths
+}
+
+// globalPathsOverlap tells if one of the global paths is a prefix of the
+// other, then the value of one depends on the other.
+func globalPathsOverlap(a, b []string) bool {
+	size := len(a)
+	if len(b) < size {
+		size = len(b)
+	}
+	for i := size - 1; i >= 0; i-- {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func isSameObjectPath(a, b eval.ObjectPath) bool {
 	if len(a) != len(b) {
 		return false
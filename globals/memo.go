@@ -0,0 +1,187 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+)
+
+const memoKeyPrefix = "globals:"
+
+// sharedMetadata are the attributes of the terramate namespace which have the
+// same value for all the stacks.
+var sharedMetadata = map[string]struct{}{
+	"git":     {},
+	"root":    {},
+	"stacks":  {},
+	"version": {},
+}
+
+// memoizer shares the values of the global expressions which don't depend on
+// the stack metadata among the evaluation of the globals of all stacks.
+//
+// The value of such an expression only depends on the expression itself and
+// on the expressions of the globals it references, then its memo key is
+// computed from the expression origin and the keys of the referenced globals
+// expressions. The same parent expression evaluated for different stacks has
+// the same key unless one of the stacks overrides a global it depends on.
+type memoizer struct {
+	cache    *config.Cache
+	exprs    map[GlobalPathKey]Expr
+	funcs    map[string]hcl.FunctionConfig
+	keys     map[GlobalPathKey]string
+	visiting map[GlobalPathKey]bool
+}
+
+func newMemoizer(
+	cache *config.Cache,
+	exprs map[GlobalPathKey]Expr,
+	funcs map[string]hcl.FunctionConfig,
+) *memoizer {
+	return &memoizer{
+		cache:    cache,
+		exprs:    exprs,
+		funcs:    funcs,
+		keys:     map[GlobalPathKey]string{},
+		visiting: map[GlobalPathKey]bool{},
+	}
+}
+
+// eval evaluates the global expression, reusing its cached value if the
+// expression doesn't depend on the stack.
+func (m *memoizer) eval(ctx *eval.Context, accessor GlobalPathKey, expr Expr) (cty.Value, error) {
+	if m == nil {
+		return ctx.EvalSensitive(expr)
+	}
+	key, ok := m.key(accessor)
+	if !ok {
+		return ctx.EvalSensitive(expr)
+	}
+	if val, ok := m.cache.Load(key); ok {
+		return val, nil
+	}
+	val, err := ctx.EvalSensitive(expr)
+	if err == nil {
+		m.cache.Store(key, val)
+	}
+	return val, err
+}
+
+// key returns the memo key of the global expression or false if the value of
+// the expression may depend on the stack.
+func (m *memoizer) key(accessor GlobalPathKey) (string, bool) {
+	if key, ok := m.keys[accessor]; ok {
+		return key, key != ""
+	}
+	if m.visiting[accessor] {
+		return "", false
+	}
+	m.visiting[accessor] = true
+	key := m.computeKey(accessor)
+	delete(m.visiting, accessor)
+	m.keys[accessor] = key
+	return key, key != ""
+}
+
+func (m *memoizer) computeKey(accessor GlobalPathKey) string {
+	expr := m.exprs[accessor]
+	if _, ok := expr.Expression.(hclsyntax.Node); !ok || isUnsetExpr(expr.Expression) {
+		return ""
+	}
+	funcs, ok := m.userFuncs(expr.Expression, map[string]bool{})
+	if !ok {
+		return ""
+	}
+
+	var deps []string
+	for _, traversal := range expr.Variables() {
+		switch traversal.RootName() {
+		case "global":
+			if len(traversal) == 1 {
+				return ""
+			}
+			varPaths := globalTraversalPath(traversal)
+			for other := range m.exprs {
+				if !globalPathsOverlap(other.Path(), varPaths) {
+					continue
+				}
+				depKey, ok := m.key(other)
+				if !ok {
+					return ""
+				}
+				deps = append(deps, depKey)
+			}
+		case "terramate":
+			if len(traversal) == 1 {
+				return ""
+			}
+			attr, ok := traversal[1].(hhcl.TraverseAttr)
+			if !ok {
+				return ""
+			}
+			if _, ok := sharedMetadata[attr.Name]; !ok {
+				return ""
+			}
+		default:
+			return ""
+		}
+	}
+	sort.Strings(deps)
+
+	h := sha256.New()
+	_, _ = h.Write([]byte(accessor.name()))
+	if accessor.isattr {
+		_, _ = h.Write([]byte{0, 1})
+	} else {
+		_, _ = h.Write([]byte{0, 0})
+	}
+	_, _ = h.Write([]byte(expr.Origin.String()))
+	for _, fn := range funcs {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(fn))
+	}
+	for _, dep := range deps {
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(dep))
+	}
+	return memoKeyPrefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// userFuncs returns the origins of the user functions called by the
+// expression, directly or through other user functions, or false if the
+// expression calls functions depending on the stack directory.
+func (m *memoizer) userFuncs(expr hhcl.Expression, visited map[string]bool) ([]string, bool) {
+	var origins []string
+	for _, name := range hcl.FunctionCalls(expr) {
+		if stdlib.IsBaseDirFunc(name) {
+			// resolves paths from the stack directory.
+			return nil, false
+		}
+		fn, ok := m.funcs[name]
+		if !ok || visited[name] {
+			continue
+		}
+		if _, ok := fn.Result.(hclsyntax.Node); !ok {
+			return nil, false
+		}
+		visited[name] = true
+		origins = append(origins, name+"@"+fn.Range.String())
+		called, ok := m.userFuncs(fn.Result, visited)
+		if !ok {
+			return nil, false
+		}
+		origins = append(origins, called...)
+	}
+	return origins, true
+}
@@ -0,0 +1,128 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGlobalsSharedAmongStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/a",
+		"s:stacks/b",
+		"s:stacks/c",
+		`f:globals.tm:globals {
+		  env    = "dev"
+		  region = "us-east-1"
+		  name   = "${global.env}-${global.region}"
+		  stack  = "${global.name}-${terramate.stack.name}"
+		  list   = tm_concat(["a"], [global.env])
+		  file   = tm_fileexists("file.txt")
+		}`,
+		`f:stacks/b/globals.tm:globals {
+		  env = "prd"
+		}`,
+		`f:stacks/c/file.txt:`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		stack string
+		env   string
+		file  bool
+	}{
+		{stack: "a", env: "dev"},
+		{stack: "b", env: "prd"},
+		{stack: "c", env: "dev", file: true},
+		{stack: "a", env: "dev"},
+	} {
+		st, err := config.LoadStack(root, project.NewPath("/stacks/"+tc.stack))
+		assert.NoError(t, err)
+
+		report := globals.ForStack(root, st)
+		assert.NoError(t, report.AsError())
+
+		got := report.Globals.AsValueMap()
+		name := tc.env + "-us-east-1"
+		assert.EqualStrings(t, name, got["name"].AsString())
+		assert.EqualStrings(t, name+"-"+tc.stack, got["stack"].AsString())
+		assert.EqualStrings(t, tc.env, got["list"].Index(cty.NumberIntVal(1)).AsString())
+		assert.IsTrue(t, got["file"].True() == tc.file,
+			"stack %s: tm_fileexists() = %s", tc.stack, got["file"].GoString())
+	}
+}
+
+func BenchmarkGlobalsForAllStacks(b *testing.B) {
+	// benchmarks the case when there are a lot of stacks sharing expensive
+	// parent globals, with only a few globals depending on the stack.
+
+	b.StopTimer()
+	s := sandbox.NoGit(b, true)
+
+	const (
+		numDirs          = 5
+		numStacksPerDir  = 10
+		numParentGlobals = 20
+	)
+
+	layout := []string{}
+	for d := 0; d < numDirs; d++ {
+		for i := 0; i < numStacksPerDir; i++ {
+			layout = append(layout, fmt.Sprintf("s:dir%d/stack%d", d, i))
+		}
+	}
+	s.BuildTree(layout)
+
+	content := "globals {\nlist = tm_range(100)\n"
+	for i := 0; i < numParentGlobals; i++ {
+		content += fmt.Sprintf("\tg_%d = [for i in global.list : i*%d]\n", i, i)
+	}
+	content += "\tstack = \"${terramate.stack.name}-${tm_length(global.g_1)}\"\n"
+	content += "}\n"
+	s.RootEntry().CreateFile("globals.tm", content)
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(b, err)
+
+	stacks, err := config.LoadAllStacks(root.Tree())
+	assert.NoError(b, err)
+
+	forAllStacks := func(b *testing.B, resetPerStack bool) {
+		root.Cache().Reset()
+		for _, elem := range stacks {
+			if resetPerStack {
+				root.Cache().Reset()
+			}
+			report := globals.ForStack(root, elem.Stack)
+			if err := report.AsError(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("not-shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			forAllStacks(b, true)
+		}
+	})
+
+	b.Run("shared", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			forAllStacks(b, false)
+		}
+	})
+}
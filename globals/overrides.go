@@ -0,0 +1,90 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"os"
+	"sort"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+// ErrOverridesFile indicates that the globals overrides file is invalid.
+const ErrOverridesFile errors.Kind = "loading globals overrides file"
+
+// LoadOverridesFile loads the global overrides defined by the globals blocks
+// of the given HCL file. The file is not part of the project configuration,
+// then only globals blocks with attributes are allowed in it.
+func LoadOverridesFile(rootdir string, filename string) ([]config.GlobalOverride, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, errors.E(ErrOverridesFile, err)
+	}
+	file, diags := hclsyntax.ParseConfig(data, filename, hhcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, errors.E(ErrOverridesFile, errors.E(hcl.ErrHCLSyntax, diags))
+	}
+
+	body := file.Body.(*hclsyntax.Body)
+	errs := errors.L()
+	for _, attr := range body.Attributes {
+		errs.Append(errors.E(ErrOverridesFile, attr.NameRange,
+			"unexpected attribute %s: only globals blocks are allowed", attr.Name))
+	}
+
+	var overrides []config.GlobalOverride
+	for _, block := range body.Blocks {
+		if block.Type != "globals" {
+			errs.Append(errors.E(ErrOverridesFile, block.TypeRange,
+				"unexpected block %s: only globals blocks are allowed", block.Type))
+			continue
+		}
+		if len(block.Labels) > 0 && !hclsyntax.ValidIdentifier(block.Labels[0]) {
+			errs.Append(errors.E(ErrOverridesFile, block.LabelRanges[0],
+				"first global label must be a valid identifier but got %s", block.Labels[0]))
+			continue
+		}
+		for _, subBlock := range block.Body.Blocks {
+			errs.Append(errors.E(ErrOverridesFile, subBlock.TypeRange,
+				"unexpected block globals.%s: only attributes are allowed", subBlock.Type))
+		}
+		names := make([]string, 0, len(block.Body.Attributes))
+		for name := range block.Body.Attributes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			attr := block.Body.Attributes[name]
+			overrides = append(overrides, config.GlobalOverride{
+				Path:   append(block.Labels[:len(block.Labels):len(block.Labels)], attr.Name),
+				Expr:   attr.Expr,
+				Origin: info.NewRange(rootdir, attr.SrcRange),
+			})
+		}
+	}
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return overrides, nil
+}
+
+// SetOverrides sets the global overrides in the expressions of the dir, then
+// they have precedence over the globals of the dir and of its parents.
+func (dirExprs HierarchicalExprs) SetOverrides(dir project.Path, overrides []config.GlobalOverride) {
+	for _, override := range overrides {
+		size := len(override.Path)
+		dirExprs.SetOverride(
+			dir,
+			NewGlobalAttrPath(override.Path[:size-1], override.Path[size-1]),
+			override.Expr,
+			override.Origin,
+		)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsOverrides(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack-a",
+		"s:stack-b",
+		`f:globals.tm:globals {
+		  env  = "dev"
+		  name = "app-${global.env}"
+		}`,
+		`f:stack-b/globals.tm:globals {
+		  env = "staging"
+		}
+		globals "net" {
+		  cidr = "10.0.0.0/16"
+		}`,
+	})
+
+	overridesFile := filepath.Join(t.TempDir(), "overrides.tm.hcl")
+	test.WriteFile(t, filepath.Dir(overridesFile), filepath.Base(overridesFile), `
+	  globals {
+	    env = "prod"
+	  }
+	  globals "net" {
+	    cidr = "10.1.0.0/16"
+	  }
+	`)
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	overrides, err := globals.LoadOverridesFile(root.HostDir(), overridesFile)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(overrides))
+
+	for _, overridden := range []bool{false, true} {
+		if overridden {
+			root.SetGlobalOverrides(overrides)
+		}
+		for _, tc := range []struct {
+			dir  string
+			want string
+		}{
+			{dir: "/stack-a", want: "app-dev"},
+			{dir: "/stack-b", want: "app-staging"},
+		} {
+			if overridden {
+				tc.want = "app-prod"
+			}
+			st, err := config.LoadStack(root, project.NewPath(tc.dir))
+			assert.NoError(t, err)
+			report := globals.ForStack(root, st)
+			assert.NoError(t, report.AsError())
+			got := report.Globals.AsValueMap()
+			assert.EqualStrings(t, tc.want, got["name"].AsString(), "stack %s", tc.dir)
+			if overridden {
+				cidr := got["net"].GetAttr("cidr").AsString()
+				assert.EqualStrings(t, "10.1.0.0/16", cidr, "stack %s", tc.dir)
+			}
+		}
+	}
+}
+
+func TestGlobalsOverridesFileInvalid(t *testing.T) {
+	t.Parallel()
+
+	for _, content := range []string{
+		`env = "prod"`,
+		`terramate {}`,
+		`globals {
+		   a {
+		     b = 1
+		   }
+		 }`,
+		`globals "1a" "b" {
+		   c = 1
+		 }`,
+		`globals {`,
+	} {
+		dir := t.TempDir()
+		test.WriteFile(t, dir, "overrides.tm.hcl", content)
+		_, err := globals.LoadOverridesFile(dir, filepath.Join(dir, "overrides.tm.hcl"))
+		assert.IsError(t, err, errors.E(globals.ErrOverridesFile), "content: %s", content)
+	}
+}
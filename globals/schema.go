@@ -0,0 +1,131 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// ErrSchema indicates that a global doesn't satisfy its global_schema.
+const ErrSchema errors.Kind = "global schema violation"
+
+// schemas returns the global schemas applying to the evaluated globals.
+// Schemas declared closer to the evaluated dir override the parent ones.
+func (dirExprs HierarchicalExprs) schemas() []hcl.GlobalSchemaConfig {
+	byName := map[string]hcl.GlobalSchemaConfig{}
+	for _, exprset := range dirExprs.sort() {
+		for name, schema := range exprset.schemas {
+			byName[name] = schema
+		}
+	}
+
+	var schemas []hcl.GlobalSchemaConfig
+	for _, schema := range byName {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool {
+		return schemas[i].Name() < schemas[j].Name()
+	})
+	return schemas
+}
+
+// validateSchemas checks the evaluated globals against the global schemas,
+// adding the violations to the report errors. The globals are replaced by
+// their values converted to the schema type.
+func (dirExprs HierarchicalExprs) validateSchemas(
+	ctx *eval.Context,
+	exprs map[GlobalPathKey]Expr,
+	report *EvalReport,
+) {
+	for _, schema := range dirExprs.schemas() {
+		key := NewGlobalAttrPath(schema.Path[:len(schema.Path)-1], schema.Path[len(schema.Path)-1])
+		if _, failed := report.Errors[key]; failed {
+			continue
+		}
+
+		expr, hasExpr := exprs[key]
+		if !hasExpr {
+			expr = Expr{
+				Origin: schema.Range,
+				Expression: &hclsyntax.LiteralValueExpr{
+					Val:      cty.NullVal(cty.DynamicPseudoType),
+					SrcRange: schema.Range.ToHCLRange(),
+				},
+			}
+		}
+
+		fail := func(format string, args ...interface{}) {
+			report.Errors[key] = EvalError{
+				Expr: expr,
+				Err: errors.E(ErrSchema, expr.Range(), "global.%s %s",
+					schema.Name(), fmt.Sprintf(format, args...)),
+			}
+		}
+
+		value, found := report.Globals.GetKeyPath(schema.Path)
+		if !found {
+			if schema.Required {
+				fail("is required by the global_schema at %s", schema.Range)
+			}
+			continue
+		}
+
+		val := valueAsCty(value)
+		converted, err := convert.Convert(val, schema.Type)
+		if err != nil {
+			fail("must be of type %s but got %s: %v (global_schema at %s)",
+				schema.Type.FriendlyNameForConstraint(), val.Type().FriendlyName(),
+				err, schema.Range)
+			continue
+		}
+		if !converted.RawEquals(val) {
+			err := report.Globals.SetAt(schema.Path, eval.NewCtyValue(converted, value.Info()))
+			if err != nil {
+				fail("failed to set the converted value: %v", err)
+				continue
+			}
+			ctx.SetNamespace("global", report.Globals.AsValueMap())
+		}
+
+		for _, validation := range schema.Validations {
+			cond, err := ctx.Eval(validation.Condition)
+			if err != nil {
+				fail("validation condition failed to evaluate: %v (validation at %s)",
+					err, validation.Range)
+				break
+			}
+			if cond.Type() != cty.Bool || cond.IsNull() || !cond.IsKnown() {
+				fail("validation condition must be a boolean (validation at %s)",
+					validation.Range)
+				break
+			}
+			if cond.True() {
+				continue
+			}
+
+			msg, err := ctx.EvalSensitive(validation.ErrorMessage)
+			if err != nil || msg.Type() != cty.String || msg.IsNull() {
+				fail("validation failed (validation at %s)", validation.Range)
+				break
+			}
+			fail("is invalid: %s (validation at %s)", eval.Redact(msg).AsString(), validation.Range)
+			break
+		}
+	}
+}
+
+func valueAsCty(v eval.Value) cty.Value {
+	if obj, ok := v.(*eval.Object); ok {
+		return cty.ObjectVal(obj.AsValueMap())
+	}
+	return v.(eval.CtyValue).Raw()
+}
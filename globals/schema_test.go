@@ -0,0 +1,285 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestGlobalSchemas(t *testing.T) {
+	t.Parallel()
+
+	type (
+		cfg struct {
+			path string
+			body string
+		}
+		testcase struct {
+			name    string
+			layout  []string
+			configs []cfg
+			want    map[string]error
+		}
+	)
+
+	const schemas = `
+	  global_schema "region" {
+	    type        = string
+	    required    = true
+	    description = "the cloud region"
+
+	    validation {
+	      condition     = tm_substr(global.region, 0, 3) == "eu-"
+	      error_message = "only EU regions are allowed"
+	    }
+	  }
+
+	  global_schema "network.cidrs" {
+	    type = list(string)
+	  }
+	`
+
+	for _, tc := range []testcase{
+		{
+			name:   "valid globals",
+			layout: []string{"s:stack"},
+			configs: []cfg{
+				{path: "/", body: schemas},
+				{
+					path: "/stack",
+					body: `
+					  globals {
+					    region = "eu-west-1"
+					  }
+					  globals "network" {
+					    cidrs = ["10.0.0.0/16"]
+					  }
+					`,
+				},
+			},
+		},
+		{
+			name:   "missing required global",
+			layout: []string{"s:stack1", "s:stack2"},
+			configs: []cfg{
+				{path: "/", body: schemas},
+				{
+					path: "/stack1",
+					body: `
+					  globals {
+					    region = "eu-west-1"
+					  }
+					`,
+				},
+			},
+			want: map[string]error{
+				"/stack2": errors.E(globals.ErrSchema),
+			},
+		},
+		{
+			name:   "wrong type",
+			layout: []string{"s:stack"},
+			configs: []cfg{
+				{path: "/", body: schemas},
+				{
+					path: "/stack",
+					body: `
+					  globals {
+					    region = "eu-west-1"
+					  }
+					  globals "network" {
+					    cidrs = "10.0.0.0/16"
+					  }
+					`,
+				},
+			},
+			want: map[string]error{
+				"/stack": errors.E(globals.ErrSchema),
+			},
+		},
+		{
+			name:   "failed validation",
+			layout: []string{"s:stack"},
+			configs: []cfg{
+				{path: "/", body: schemas},
+				{
+					path: "/stack",
+					body: `
+					  globals {
+					    region = "us-east-1"
+					  }
+					`,
+				},
+			},
+			want: map[string]error{
+				"/stack": errors.E(globals.ErrSchema),
+			},
+		},
+		{
+			name:   "child schema overrides parent schema",
+			layout: []string{"s:stack"},
+			configs: []cfg{
+				{path: "/", body: schemas},
+				{
+					path: "/stack",
+					body: `
+					  global_schema "region" {
+					    type = string
+					  }
+					  globals {
+					    region = "us-east-1"
+					  }
+					`,
+				},
+			},
+		},
+		{
+			name:   "schemas from parent without globals",
+			layout: []string{"s:dir/stack"},
+			configs: []cfg{
+				{path: "/dir", body: schemas},
+				{
+					path: "/dir/stack",
+					body: `
+					  globals {
+					    region = 1
+					  }
+					`,
+				},
+			},
+			want: map[string]error{
+				"/dir/stack": errors.E(globals.ErrSchema),
+			},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			for _, c := range tc.configs {
+				path := filepath.Join(s.RootDir(), c.path)
+				test.AppendFile(t, path, config.DefaultFilename, c.body)
+			}
+
+			stacks, err := config.LoadAllStacks(s.Config().Tree())
+			assert.NoError(t, err)
+			for _, elem := range stacks {
+				report := globals.ForStack(s.Config(), elem.Stack)
+				errtest.Assert(t, report.AsError(), tc.want[elem.Stack.Dir.String()])
+			}
+		})
+	}
+}
+
+func TestGlobalSchemaParsingErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{
+		`global_schema {
+		   type = string
+		 }`,
+		`global_schema "a" {
+		   type = strin
+		 }`,
+		`global_schema "a" {
+		   required = "yes"
+		 }`,
+		`global_schema "a" {
+		   validation {
+		     condition = true
+		   }
+		 }`,
+		`global_schema "a" {}
+		 global_schema "a" {}`,
+	} {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{"s:stack"})
+		test.AppendFile(t, s.RootDir(), config.DefaultFilename, body)
+
+		_, err := config.LoadRoot(s.RootDir())
+		errtest.Assert(t, err, errors.E(hcl.ErrTerramateSchema))
+	}
+}
+
+func TestGlobalSchemasConvertValues(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:globals.tm:
+		  global_schema "port" {
+		    type = number
+		  }
+
+		  global_schema "zones" {
+		    type = set(string)
+		  }
+
+		  globals {
+		    port  = "8080"
+		    zones = ["a", "b", "a"]
+		  }
+		`,
+	})
+
+	stacks, err := config.LoadAllStacks(s.Config().Tree())
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(stacks))
+
+	report := globals.ForStack(s.Config(), stacks[0].Stack)
+	assert.NoError(t, report.AsError())
+
+	got := report.Globals.AsValueMap()
+	if !got["port"].RawEquals(cty.NumberIntVal(8080)) {
+		t.Fatalf("global.port = %#v, want the number 8080", got["port"])
+	}
+	wantZones := cty.SetVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")})
+	if !got["zones"].RawEquals(wantZones) {
+		t.Fatalf("global.zones = %#v, want %#v", got["zones"], wantZones)
+	}
+}
+
+func TestGlobalSchemasNotCheckedOutsideStacks(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:dir/stack",
+		`f:globals.tm:
+		  global_schema "region" {
+		    type     = string
+		    required = true
+		  }
+		`,
+		`f:dir/stack/globals.tm:
+		  globals {
+		    region = "eu-west-1"
+		  }
+		`,
+	})
+
+	for _, dir := range []string{"/", "/dir"} {
+		ctx := eval.NewContext(stdlib.Functions(s.RootDir()))
+		report := globals.ForDir(s.Config(), project.NewPath(dir), ctx)
+		assert.NoError(t, report.AsError(), "dir %s", dir)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsSensitive(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:globals.tm:globals {
+		  token = tm_sensitive("secret")
+		}`,
+		`f:stack/globals.tm:globals {
+		  url   = "https://${global.token}@example.com"
+		  plain = "visible"
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	assert.NoError(t, report.AsError())
+
+	got := report.Globals.AsValueMap()
+	assert.IsTrue(t, eval.IsSensitive(got["token"]))
+	assert.IsTrue(t, eval.IsSensitive(got["url"]))
+	assert.IsTrue(t, !eval.IsSensitive(got["plain"]))
+
+	str := report.Globals.String()
+	if strings.Contains(str, "secret") || !strings.Contains(str, "visible") {
+		t.Fatalf("sensitive globals not redacted: %s", str)
+	}
+}
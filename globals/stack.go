@@ -4,18 +4,55 @@
 package globals
 
 import (
+	hhcl "github.com/hashicorp/hcl/v2"
 	"github.com/terramate-io/terramate/config"
-	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/stdlib"
 )
 
 // ForStack loads from the config tree all globals defined for a given stack.
+// The values of the globals expressions which don't depend on the stack are
+// evaluated once and shared by all the stacks through the root cache.
 func ForStack(root *config.Root, stack *config.Stack) EvalReport {
-	ctx := eval.NewContext(
-		stdlib.Functions(stack.HostDir(root)),
-	)
-	runtime := root.Runtime()
-	runtime.Merge(stack.RuntimeValues(root))
-	ctx.SetNamespace("terramate", runtime)
-	return ForDir(root, stack.Dir, ctx)
+	return forStack(root, stack, allGlobals, nil)
+}
+
+// ForStackOnly loads from the config tree only the globals of the stack
+// referenced by the given traversals and the globals they depend on. See
+// [HierarchicalExprs.EvalOnly].
+func ForStackOnly(root *config.Root, stack *config.Stack, traversals []hhcl.Traversal) EvalReport {
+	return forStack(root, stack, traversals, nil)
+}
+
+// forStack loads the globals of the stack needed by the traversals, where
+// chain has the stacks whose globals are being evaluated and reference the
+// stack through the stacks namespace.
+func forStack(
+	root *config.Root,
+	stack *config.Stack,
+	traversals []hhcl.Traversal,
+	chain []project.Path,
+) EvalReport {
+	ctx := root.NewStackEvalContext(stack, stdlib.Functions(stack.EvalDir(root)))
+
+	tree, ok := root.Lookup(stack.Dir)
+	if !ok {
+		return NewEvalReport()
+	}
+	exprs, err := LoadExprs(tree)
+	if err != nil {
+		report := NewEvalReport()
+		report.BootstrapErr = err
+		return report
+	}
+	exprs.SetOverrides(stack.Dir, root.GlobalOverrides())
+	exprs = exprs.only(traversals)
+
+	chain = append(chain[:len(chain):len(chain)], stack.Dir)
+	if err := setStacksNamespace(ctx, root, exprs.stacksTraversals(), chain); err != nil {
+		report := NewEvalReport()
+		report.BootstrapErr = err
+		return report
+	}
+	return exprs.eval(ctx, root.Cache(), tree.Functions(), true)
 }
@@ -0,0 +1,245 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StacksNamespace is the namespace exposing the globals and metadata of
+// other stacks, as stacks.by_path["<path>"] and stacks.by_id["<id>"].
+const StacksNamespace = "stacks"
+
+// Errors returned when resolving the stacks namespace.
+const (
+	ErrStackRef      errors.Kind = "invalid stack reference"
+	ErrStackRefCycle errors.Kind = "cycle in stack references"
+)
+
+const (
+	stacksByPath = "by_path"
+	stacksByID   = "by_id"
+)
+
+type stackRef struct {
+	kind string
+	key  string
+	rng  hhcl.Range
+
+	// globals are the traversals of the referenced globals of the stack,
+	// rooted at the global namespace.
+	globals []hhcl.Traversal
+}
+
+// StacksTraversals returns the traversals of the stacks namespace found in
+// the HCL nodes.
+func StacksTraversals(nodes ...hclsyntax.Node) []hhcl.Traversal {
+	return namespaceTraversals(StacksNamespace, nodes)
+}
+
+// Traversals returns the traversals of the global namespace found in the HCL
+// nodes, which can be used to evaluate only the referenced globals with
+// [ForStackOnly].
+func Traversals(nodes ...hclsyntax.Node) []hhcl.Traversal {
+	return namespaceTraversals("global", nodes)
+}
+
+func namespaceTraversals(namespace string, nodes []hclsyntax.Node) []hhcl.Traversal {
+	var traversals []hhcl.Traversal
+	for _, node := range nodes {
+		if node == nil {
+			continue
+		}
+		_ = hclsyntax.VisitAll(node, func(node hclsyntax.Node) hhcl.Diagnostics {
+			if expr, ok := node.(*hclsyntax.ScopeTraversalExpr); ok &&
+				expr.Traversal.RootName() == namespace {
+				traversals = append(traversals, expr.Traversal)
+			}
+			return nil
+		})
+	}
+	return traversals
+}
+
+// SetStacksNamespace sets the stacks namespace in the evaluation context with
+// the globals and metadata of the stacks referenced by the traversals. Only
+// the referenced stacks have their globals evaluated, and the namespace is
+// not set if there are no references.
+func SetStacksNamespace(ctx *eval.Context, root *config.Root, traversals []hhcl.Traversal) error {
+	return setStacksNamespace(ctx, root, traversals, nil)
+}
+
+func setStacksNamespace(
+	ctx *eval.Context,
+	root *config.Root,
+	traversals []hhcl.Traversal,
+	chain []project.Path,
+) error {
+	refs, err := parseStackRefs(traversals)
+	if err != nil || len(refs) == 0 {
+		return err
+	}
+
+	byPath := map[string]cty.Value{}
+	byID := map[string]cty.Value{}
+	for _, ref := range refs {
+		var dir project.Path
+		switch ref.kind {
+		case stacksByPath:
+			if !strings.HasPrefix(ref.key, "/") {
+				return errors.E(ErrStackRef, ref.rng,
+					"stacks.by_path key %q must be an absolute project path", ref.key)
+			}
+			dir = project.NewPath(ref.key)
+		case stacksByID:
+			node, ok := root.StackByID(ref.key)
+			if !ok {
+				return errors.E(ErrStackRef, ref.rng,
+					"stacks.by_id references unknown stack ID %q", ref.key)
+			}
+			dir = node.Dir()
+		}
+
+		val, err := stackRefValue(root, dir, ref, chain)
+		if err != nil {
+			return err
+		}
+		if ref.kind == stacksByPath {
+			byPath[ref.key] = val
+		} else {
+			byID[ref.key] = val
+		}
+	}
+
+	ctx.SetNamespace(StacksNamespace, map[string]cty.Value{
+		stacksByPath: cty.ObjectVal(byPath),
+		stacksByID:   cty.ObjectVal(byID),
+	})
+	return nil
+}
+
+func stackRefValue(root *config.Root, dir project.Path, ref stackRef, chain []project.Path) (cty.Value, error) {
+	for i, visited := range chain {
+		if visited != dir {
+			continue
+		}
+		var cycle []string
+		for _, p := range chain[i:] {
+			cycle = append(cycle, p.String())
+		}
+		cycle = append(cycle, dir.String())
+		return cty.NilVal, errors.E(ErrStackRefCycle, ref.rng,
+			"globals of the stacks reference each other: %s", strings.Join(cycle, " -> "))
+	}
+
+	st, found, err := config.TryLoadStack(root, dir)
+	if err != nil {
+		return cty.NilVal, errors.E(ErrStackRef, ref.rng, err)
+	}
+	if !found {
+		return cty.NilVal, errors.E(ErrStackRef, ref.rng,
+			"stacks.%s[%q] is not a stack", ref.kind, ref.key)
+	}
+
+	report := forStack(root, st, ref.globals, chain)
+	if err := report.AsError(); err != nil {
+		return cty.NilVal, errors.E(ErrStackRef, ref.rng, err,
+			"evaluating globals of stack %s", st.Dir)
+	}
+	return cty.ObjectVal(map[string]cty.Value{
+		"global": cty.ObjectVal(report.Globals.AsValueMap()),
+		"stack":  st.RuntimeValues(root)["stack"],
+	}), nil
+}
+
+// parseStackRefs returns the referenced stacks, sorted by kind and key.
+func parseStackRefs(traversals []hhcl.Traversal) ([]stackRef, error) {
+	seen := map[[2]string]int{}
+	var refs []stackRef
+	for _, traversal := range traversals {
+		if traversal.RootName() != StacksNamespace {
+			continue
+		}
+		ref, err := parseStackRef(traversal)
+		if err != nil {
+			return nil, err
+		}
+		if i, ok := seen[[2]string{ref.kind, ref.key}]; ok {
+			refs[i].globals = append(refs[i].globals, ref.globals...)
+			continue
+		}
+		seen[[2]string{ref.kind, ref.key}] = len(refs)
+		refs = append(refs, ref)
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].kind != refs[j].kind {
+			return refs[i].kind < refs[j].kind
+		}
+		return refs[i].key < refs[j].key
+	})
+	return refs, nil
+}
+
+func parseStackRef(traversal hhcl.Traversal) (stackRef, error) {
+	invalid := func() (stackRef, error) {
+		return stackRef{}, errors.E(ErrStackRef, traversal.SourceRange(),
+			`stacks must be accessed as stacks.by_path["<path>"] or stacks.by_id["<id>"] with literal keys`)
+	}
+	if len(traversal) < 3 {
+		return invalid()
+	}
+	kind, ok := traversal[1].(hhcl.TraverseAttr)
+	if !ok || (kind.Name != stacksByPath && kind.Name != stacksByID) {
+		return invalid()
+	}
+	ref := stackRef{
+		kind: kind.Name,
+		rng:  traversal.SourceRange(),
+	}
+	switch key := traversal[2].(type) {
+	case hhcl.TraverseIndex:
+		if key.Key.Type() != cty.String || !key.Key.IsKnown() || key.Key.IsNull() {
+			return invalid()
+		}
+		ref.key = key.Key.AsString()
+	case hhcl.TraverseAttr:
+		ref.key = key.Name
+	default:
+		return invalid()
+	}
+	ref.globals = stackRefGlobals(traversal[3:])
+	return ref, nil
+}
+
+// stackRefGlobals returns the global traversal of the steps following the
+// stack reference: stacks.by_path["/a"].global.name references global.name
+// of the stack, stacks.by_path["/a"].stack references no globals and any
+// other reference is handled as a reference to all globals.
+func stackRefGlobals(steps hhcl.Traversal) []hhcl.Traversal {
+	if len(steps) == 0 {
+		return allGlobals
+	}
+	attr, ok := steps[0].(hhcl.TraverseAttr)
+	if !ok {
+		return allGlobals
+	}
+	switch attr.Name {
+	case "stack":
+		return nil
+	case "global":
+	default:
+		return allGlobals
+	}
+	traversal := hhcl.Traversal{hhcl.TraverseRoot{Name: "global", SrcRange: attr.SrcRange}}
+	return []hhcl.Traversal{append(traversal, steps[1:]...)}
+}
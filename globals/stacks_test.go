@@ -0,0 +1,149 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsStacksNamespace(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:network/stack.tm:stack {
+		  name = "network"
+		  id   = "network-id"
+		}
+		globals {
+		  vpc_cidr = "10.0.0.0/16"
+		  # not referenced by other stacks, then not evaluated for them.
+		  failing = tm_upper(1, 2)
+		}`,
+		`f:app/stack.tm:stack {
+		}
+		globals {
+		  vpc_cidr     = stacks.by_path["/network"].global.vpc_cidr
+		  network_name = stacks.by_id["network-id"].stack.name
+		  network_path = stacks.by_id.network-id.stack.path.absolute
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/app"))
+	assert.NoError(t, err)
+
+	report := globals.ForStack(root, st)
+	assert.NoError(t, report.AsError())
+
+	got := report.Globals.AsValueMap()
+	assert.EqualStrings(t, "10.0.0.0/16", got["vpc_cidr"].AsString())
+	assert.EqualStrings(t, "network", got["network_name"].AsString())
+	assert.EqualStrings(t, "/network", got["network_path"].AsString())
+}
+
+func TestGlobalsStacksNamespaceErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		name   string
+		layout []string
+		want   errors.Kind
+	}{
+		{
+			name: "cycle",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  a = stacks.by_path["/b"].global.b
+				}`,
+				`f:b/stack.tm:stack {}
+				globals {
+				  b = stacks.by_path["/a"].global.a
+				}`,
+			},
+			want: globals.ErrStackRefCycle,
+		},
+		{
+			name: "self reference",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  a = stacks.by_path["/a"].global.b
+				}`,
+			},
+			want: globals.ErrStackRefCycle,
+		},
+		{
+			name: "not a stack",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  a = stacks.by_path["/b"].global.b
+				}`,
+				`f:b/globals.tm:globals {
+				  b = 1
+				}`,
+			},
+			want: globals.ErrStackRef,
+		},
+		{
+			name: "unknown id",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  a = stacks.by_id["unknown"].global.b
+				}`,
+			},
+			want: globals.ErrStackRef,
+		},
+		{
+			name: "relative path",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  a = stacks.by_path["b"].global.b
+				}`,
+				`f:b/stack.tm:stack {}`,
+			},
+			want: globals.ErrStackRef,
+		},
+		{
+			name: "dynamic key",
+			layout: []string{
+				`f:a/stack.tm:stack {}
+				globals {
+				  path = "/b"
+				  a    = stacks.by_path[global.path].global.b
+				}`,
+				`f:b/stack.tm:stack {}`,
+			},
+			want: globals.ErrStackRef,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			root, err := config.LoadRoot(s.RootDir())
+			assert.NoError(t, err)
+			st, err := config.LoadStack(root, project.NewPath("/a"))
+			assert.NoError(t, err)
+
+			report := globals.ForStack(root, st)
+			assert.IsError(t, report.AsError(), errors.E(tc.want))
+		})
+	}
+}
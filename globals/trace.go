@@ -0,0 +1,212 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals
+
+import (
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+)
+
+type (
+	// Trace is the provenance of a global: where it is defined and which
+	// definitions of the parent directories it overrides or is merged with.
+	Trace struct {
+		// Path is the global accessor path (labels + attribute name).
+		Path []string
+
+		// Definition is the effective definition of the global.
+		Definition TraceEntry
+
+		// Overridden are the definitions overridden by the effective one,
+		// from the closest to the farthest directory. It includes the
+		// definitions of nested globals (eg.: global.obj.a) replaced by the
+		// definition of the whole object (eg.: global.obj) in a child
+		// directory.
+		Overridden []TraceEntry
+
+		// Merged are the definitions of the enclosing objects or of the
+		// nested globals which are merged with the effective definition. Eg.:
+		// a child `globals "obj" { a = 1 }` extending a parent `obj = {...}`.
+		Merged []TraceEntry
+	}
+
+	// TraceEntry is a definition of a global.
+	TraceEntry struct {
+		// Dir is the configuration directory defining the global.
+		Dir project.Path
+
+		// Origin is the range of the expression defining the global.
+		Origin info.Range
+
+		// Imports is the chain of files importing the definition, starting at
+		// the file of Dir with the import block and ending at the file
+		// importing the definition file. It's empty if the definition is not
+		// imported.
+		Imports []project.Path
+
+		// Unset tells if the definition unsets the global.
+		Unset bool
+	}
+)
+
+// Name returns the global name, as accessed in the global namespace.
+func (t Trace) Name() string {
+	return strings.Join(t.Path, ".")
+}
+
+// Imported tells if the definition comes from a file imported by the
+// configuration of Dir.
+func (e TraceEntry) Imported() bool {
+	return len(e.Imports) > 0
+}
+
+// TraceForStack returns the provenance of the globals of the stack.
+func TraceForStack(root *config.Root, stack *config.Stack) ([]Trace, error) {
+	tree, ok := root.Lookup(stack.Dir)
+	if !ok {
+		return nil, nil
+	}
+	exprs, err := LoadExprs(tree)
+	if err != nil {
+		return nil, err
+	}
+	traces := exprs.Trace()
+	setImports := func(entries []TraceEntry) {
+		for i := range entries {
+			entries[i].Imports = importChain(root, entries[i])
+		}
+	}
+	for i := range traces {
+		trace := &traces[i]
+		trace.Definition.Imports = importChain(root, trace.Definition)
+		setImports(trace.Overridden)
+		setImports(trace.Merged)
+	}
+	return traces, nil
+}
+
+// Trace returns the provenance of each global attribute, sorted by the global
+// path. The import chains of the definitions are not set, see [TraceForStack].
+func (dirExprs HierarchicalExprs) Trace() []Trace {
+	traces := map[GlobalPathKey]*Trace{}
+	for _, exprset := range dirExprs.sort() {
+		for key, expr := range exprset.expressions {
+			if !key.isattr {
+				continue
+			}
+			entry := TraceEntry{
+				Dir:    exprset.origin,
+				Origin: expr.Origin,
+				Unset:  isUnsetExpr(expr.Expression),
+			}
+			trace, ok := traces[key]
+			if !ok {
+				traces[key] = &Trace{
+					Path:       key.Path(),
+					Definition: entry,
+				}
+				continue
+			}
+			trace.Overridden = append([]TraceEntry{trace.Definition}, trace.Overridden...)
+			trace.Definition = entry
+		}
+	}
+
+	sorted := make([]*Trace, 0, len(traces))
+	for _, trace := range traces {
+		sorted = append(sorted, trace)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Name() < sorted[j].Name()
+	})
+
+	// An object defined in a child directory replaces the nested globals
+	// of the parent directories, which are then reported as overridden by
+	// it. The remaining nested globals are merged with their objects.
+	overridden := map[*Trace]bool{}
+	for _, obj := range sorted {
+		for _, nested := range sorted {
+			if !isNestedPath(obj.Path, nested.Path) ||
+				!isChildDir(obj.Definition.Dir, nested.Definition.Dir) {
+				continue
+			}
+			overridden[nested] = true
+			obj.Overridden = append(obj.Overridden, nested.Definition)
+			obj.Overridden = append(obj.Overridden, nested.Overridden...)
+		}
+	}
+	for _, obj := range sorted {
+		if overridden[obj] || obj.Definition.Unset {
+			continue
+		}
+		for _, nested := range sorted {
+			if overridden[nested] || nested.Definition.Unset ||
+				!isNestedPath(obj.Path, nested.Path) {
+				continue
+			}
+			obj.Merged = append(obj.Merged, nested.Definition)
+			nested.Merged = append(nested.Merged, obj.Definition)
+		}
+	}
+
+	res := make([]Trace, 0, len(sorted))
+	for _, trace := range sorted {
+		if overridden[trace] {
+			continue
+		}
+		sort.SliceStable(trace.Overridden, func(i, j int) bool {
+			return len(trace.Overridden[i].Dir.String()) > len(trace.Overridden[j].Dir.String())
+		})
+		res = append(res, *trace)
+	}
+	return res
+}
+
+// importChain returns the chain of files importing the definition of the
+// entry, from the configuration of the entry dir to the definition file.
+func importChain(root *config.Root, entry TraceEntry) []project.Path {
+	tree, ok := root.Lookup(entry.Dir)
+	if !ok {
+		return nil
+	}
+	var chain []project.Path
+	file := entry.Origin.HostPath()
+	for {
+		importer, ok := tree.Node.ImportedBy[file]
+		if !ok {
+			break
+		}
+		chain = append([]project.Path{project.PrjAbsPath(root.HostDir(), importer)}, chain...)
+		file = importer
+	}
+	return chain
+}
+
+// isNestedPath tells if the global path nested is inside the object path obj.
+func isNestedPath(obj, nested []string) bool {
+	if len(nested) <= len(obj) {
+		return false
+	}
+	for i, name := range obj {
+		if nested[i] != name {
+			return false
+		}
+	}
+	return true
+}
+
+// isChildDir tells if dir is a strict child directory of parent.
+func isChildDir(dir, parent project.Path) bool {
+	return dir != parent && dir.HasPrefix(parent.String())
+}
+
+func isUnsetExpr(expr hhcl.Expression) bool {
+	traversal, diags := hhcl.AbsTraversalForExpr(expr)
+	return !diags.HasErrors() && len(traversal) == 1 && traversal.RootName() == "unset"
+}
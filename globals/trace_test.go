@@ -0,0 +1,98 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package globals_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestGlobalsTrace(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/stack",
+		`f:globals.tm:globals {
+		  region = "us-east-1"
+		  env    = "dev"
+		  obj    = { b = 2 }
+		}
+		globals "cfg" {
+		  x = 1
+		}`,
+		`f:stacks/globals.tm:import {
+		  source = "/modules/common.tm"
+		}
+		globals {
+		  region = "us-west-1"
+		  cfg    = { y = 2 }
+		}`,
+		`f:stacks/stack/globals.tm:globals "obj" {
+		  a = 1
+		}`,
+		`f:modules/common.tm:import {
+		  source = "/shared/env.tm"
+		}`,
+		`f:shared/env.tm:globals {
+		  env = unset
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stacks/stack"))
+	assert.NoError(t, err)
+
+	traces, err := globals.TraceForStack(root, st)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 5, len(traces))
+
+	cfg := traces[0]
+	assert.EqualStrings(t, "cfg", cfg.Name())
+	assert.EqualStrings(t, "/stacks", cfg.Definition.Dir.String())
+	assert.EqualInts(t, 1, len(cfg.Overridden))
+	assert.EqualStrings(t, "/", cfg.Overridden[0].Dir.String())
+	assert.EqualInts(t, 0, len(cfg.Merged))
+
+	env := traces[1]
+	assert.EqualStrings(t, "env", env.Name())
+	assert.EqualStrings(t, "/stacks", env.Definition.Dir.String())
+	assert.EqualStrings(t, "/shared/env.tm", env.Definition.Origin.Path().String())
+	assert.IsTrue(t, env.Definition.Imported())
+	assert.EqualInts(t, 2, len(env.Definition.Imports))
+	assert.EqualStrings(t, "/stacks/globals.tm", env.Definition.Imports[0].String())
+	assert.EqualStrings(t, "/modules/common.tm", env.Definition.Imports[1].String())
+	assert.IsTrue(t, env.Definition.Unset)
+	assert.EqualInts(t, 1, len(env.Overridden))
+	assert.EqualStrings(t, "/globals.tm", env.Overridden[0].Origin.Path().String())
+	assert.IsTrue(t, !env.Overridden[0].Imported())
+
+	obj := traces[2]
+	assert.EqualStrings(t, "obj", obj.Name())
+	assert.EqualStrings(t, "/", obj.Definition.Dir.String())
+	assert.EqualInts(t, 0, len(obj.Overridden))
+	assert.EqualInts(t, 1, len(obj.Merged))
+	assert.EqualStrings(t, "/stacks/stack/globals.tm", obj.Merged[0].Origin.Path().String())
+
+	objA := traces[3]
+	assert.EqualStrings(t, "obj.a", objA.Name())
+	assert.EqualStrings(t, "/stacks/stack/globals.tm", objA.Definition.Origin.Path().String())
+	assert.EqualInts(t, 0, len(objA.Overridden))
+	assert.EqualInts(t, 1, len(objA.Merged))
+	assert.EqualStrings(t, "/globals.tm", objA.Merged[0].Origin.Path().String())
+
+	region := traces[4]
+	assert.EqualStrings(t, "region", region.Name())
+	assert.EqualStrings(t, "/stacks/globals.tm", region.Definition.Origin.Path().String())
+	assert.IsTrue(t, !region.Definition.Imported())
+	assert.IsTrue(t, !region.Definition.Unset)
+	assert.EqualInts(t, 1, len(region.Overridden))
+	assert.EqualStrings(t, "/", region.Overridden[0].Dir.String())
+}
@@ -3,6 +3,7 @@ module github.com/terramate-io/terramate
 go 1.18
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/alecthomas/kong v0.7.1
 	github.com/apparentlymart/go-versions v1.0.1
 	github.com/cli/go-gh/v2 v2.1.0
@@ -26,6 +27,7 @@ require (
 	github.com/willabides/kongplete v0.2.0
 	github.com/zclconf/go-cty v1.13.2
 	github.com/zclconf/go-cty-debug v0.0.0-20191215020915-b22d67c1ba0b
+	github.com/zclconf/go-cty-yaml v1.0.2
 	go.lsp.dev/jsonrpc2 v0.10.0
 	go.lsp.dev/protocol v0.12.0
 	go.lsp.dev/uri v0.3.0
@@ -67,7 +69,6 @@ require (
 	github.com/mitchellh/go-homedir v1.1.0 // indirect
 	github.com/mitchellh/go-wordwrap v1.0.0 // indirect
 	github.com/rs/zerolog v1.28.0
-	github.com/zclconf/go-cty-yaml v1.0.2 // indirect
 	golang.org/x/crypto v0.13.0 // indirect
 	golang.org/x/net v0.15.0 // indirect
 	golang.org/x/sys v0.12.0
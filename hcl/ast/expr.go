@@ -33,7 +33,11 @@ func TokensForExpression(expr hcl.Expression) hclwrite.Tokens {
 }
 
 // TokensForValue returns the tokens for the provided value.
+// Marked values are written with their real value.
 func TokensForValue(value cty.Value) hclwrite.Tokens {
+	if value.ContainsMarked() {
+		value, _ = value.UnmarkDeep()
+	}
 	if value.Type() == customdecode.ExpressionClosureType {
 		closureExpr := value.EncapsulatedValue().(*customdecode.ExpressionClosure)
 		return TokensForExpression(closureExpr.Expression)
@@ -19,6 +19,9 @@ const ErrEval errors.Kind = "eval expression"
 // Context is used to evaluate HCL code.
 type Context struct {
 	hclctx *hhcl.EvalContext
+	lazy   []*lazyValue
+
+	keepSensitive bool
 }
 
 // NewContext creates a new HCL evaluation context.
@@ -76,7 +79,22 @@ func (c *Context) HasNamespace(name string) bool {
 }
 
 // Eval will evaluate an expression given its context.
+// The returned value is the real value, without the sensitive marks.
 func (c *Context) Eval(expr hhcl.Expression) (cty.Value, error) {
+	val, err := c.EvalSensitive(expr)
+	if err != nil {
+		return cty.NilVal, err
+	}
+	return unmarkSensitive(val), nil
+}
+
+// EvalSensitive evaluates the expression like Eval but keeps the sensitive
+// marks of the value, then the caller can tell if it must be redacted.
+// See IsSensitive and Redact.
+func (c *Context) EvalSensitive(expr hhcl.Expression) (cty.Value, error) {
+	if err := c.resolveLazyValues(expr); err != nil {
+		return cty.NilVal, err
+	}
 	val, diag := expr.Value(c.hclctx)
 	if diag.HasErrors() {
 		return cty.NilVal, errors.E(ErrEval, diag)
@@ -89,6 +107,9 @@ func (c *Context) Eval(expr hhcl.Expression) (cty.Value, error) {
 // with  no reference to terramate namespaced variables (globals and terramate)
 // and functions (tm_ prefixed functions).
 func (c *Context) PartialEval(expr hhcl.Expression) (hhcl.Expression, error) {
+	if err := c.resolveLazyValues(expr); err != nil {
+		return nil, errors.E(ErrPartial, err)
+	}
 	newexpr, err := c.partialEval(expr)
 	if err != nil {
 		return nil, errors.E(ErrPartial, err)
@@ -96,6 +117,15 @@ func (c *Context) PartialEval(expr hhcl.Expression) (hhcl.Expression, error) {
 	return newexpr, nil
 }
 
+// PartialEvalSensitive partially evaluates the expression like PartialEval but
+// keeps the sensitive marks of the evaluated values embedded in the returned
+// expression. The result must be redacted with RedactExpr before displaying.
+func (c *Context) PartialEvalSensitive(expr hhcl.Expression) (hhcl.Expression, error) {
+	sensitive := *c
+	sensitive.keepSensitive = true
+	return sensitive.PartialEval(expr)
+}
+
 // Copy the eval context.
 func (c *Context) Copy() *Context {
 	newctx := &hhcl.EvalContext{
@@ -105,7 +135,9 @@ func (c *Context) Copy() *Context {
 	for k, v := range c.hclctx.Variables {
 		newctx.Variables[k] = v
 	}
-	return NewContextFrom(newctx)
+	copied := NewContextFrom(newctx)
+	copied.lazy = append(copied.lazy, c.lazy...)
+	return copied
 }
 
 // Unwrap returns the internal hhcl.EvalContext.
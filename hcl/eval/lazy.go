@@ -0,0 +1,118 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package eval
+
+import (
+	"strings"
+	"sync"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// LazyValue computes a value of the evaluation context. It's only called if
+// an evaluated expression references the value.
+type LazyValue func() (cty.Value, error)
+
+type lazyValue struct {
+	path []string
+	fn   LazyValue
+
+	once sync.Once
+	val  cty.Value
+	err  error
+}
+
+// SetLazyValue sets the value at the attribute path, starting by the namespace
+// name, to be computed by fn when an evaluated expression references it.
+// The value is computed at most once, even if the context is copied.
+func (c *Context) SetLazyValue(path []string, fn LazyValue) {
+	c.lazy = append(c.lazy, &lazyValue{
+		path: path,
+		fn:   fn,
+	})
+}
+
+// resolveLazyValues computes the lazy values referenced by the expression and
+// sets them in the context.
+func (c *Context) resolveLazyValues(expr hhcl.Expression) error {
+	if len(c.lazy) == 0 {
+		return nil
+	}
+	traversals := expr.Variables()
+	for _, lazy := range c.lazy {
+		if !lazy.referencedBy(traversals) {
+			continue
+		}
+		lazy.once.Do(func() {
+			lazy.val, lazy.err = lazy.fn()
+		})
+		if lazy.err != nil {
+			return errors.E(ErrEval, expr.Range(), lazy.err,
+				"evaluating %s", strings.Join(lazy.path, "."))
+		}
+		ns, ok := c.hclctx.Variables[lazy.path[0]]
+		if !ok {
+			ns = cty.EmptyObjectVal
+		}
+		c.hclctx.Variables[lazy.path[0]] = setAttrPath(ns, lazy.path[1:], lazy.val)
+	}
+	return nil
+}
+
+// referencedBy tells if any of the traversals references the lazy value, a
+// value nested inside it or the object holding it. References to the upper
+// objects, like the whole namespace, don't compute the lazy value.
+func (lazy *lazyValue) referencedBy(traversals []hhcl.Traversal) bool {
+	minDepth := len(lazy.path) - 1
+	for _, traversal := range traversals {
+		if traversal.RootName() != lazy.path[0] {
+			continue
+		}
+		depth := 1
+		overlap := true
+		for _, step := range traversal[1:] {
+			if depth >= len(lazy.path) {
+				break
+			}
+			switch step := step.(type) {
+			case hhcl.TraverseAttr:
+				overlap = step.Name == lazy.path[depth]
+			case hhcl.TraverseIndex:
+				if step.Key.Type() == cty.String && step.Key.IsKnown() && !step.Key.IsNull() {
+					overlap = step.Key.AsString() == lazy.path[depth]
+				}
+			}
+			if !overlap {
+				break
+			}
+			depth++
+		}
+		if overlap && depth >= minDepth {
+			return true
+		}
+	}
+	return false
+}
+
+// setAttrPath returns a copy of the object value with the attribute at path
+// set to val, creating the intermediate objects as needed.
+func setAttrPath(obj cty.Value, path []string, val cty.Value) cty.Value {
+	if len(path) == 0 {
+		return val
+	}
+	attrs := map[string]cty.Value{}
+	if obj.Type().IsObjectType() && obj.IsKnown() && !obj.IsNull() {
+		for name, attr := range obj.AsValueMap() {
+			attrs[name] = attr
+		}
+	}
+	child, ok := attrs[path[0]]
+	if !ok {
+		child = cty.EmptyObjectVal
+	}
+	attrs[path[0]] = setAttrPath(child, path[1:], val)
+	return cty.ObjectVal(attrs)
+}
@@ -0,0 +1,80 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package eval_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestLazyValues(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		expr     string
+		want     cty.Value
+		computed bool
+	}
+
+	for _, tc := range []testcase{
+		{
+			expr: `ns.eager`,
+			want: cty.StringVal("eager"),
+		},
+		{
+			expr: `tm_length(ns)`,
+			want: cty.NumberIntVal(1),
+		},
+		{
+			expr:     `ns.obj.lazy`,
+			want:     cty.StringVal("lazy"),
+			computed: true,
+		},
+		{
+			expr:     `ns["obj"]["lazy"]`,
+			want:     cty.StringVal("lazy"),
+			computed: true,
+		},
+		{
+			expr: `ns.obj`,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"lazy": cty.StringVal("lazy"),
+			}),
+			computed: true,
+		},
+	} {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			calls := 0
+			ctx := eval.NewContext(stdlib.Functions(test.TempDir(t)))
+			ctx.SetNamespace("ns", map[string]cty.Value{
+				"eager": cty.StringVal("eager"),
+			})
+			ctx.SetLazyValue([]string{"ns", "obj", "lazy"}, func() (cty.Value, error) {
+				calls++
+				return cty.StringVal("lazy"), nil
+			})
+
+			for i := 0; i < 2; i++ {
+				got, err := ctx.Eval(test.NewExpr(t, tc.expr))
+				assert.NoError(t, err)
+				assert.IsTrue(t, got.RawEquals(tc.want), "got %s want %s",
+					got.GoString(), tc.want.GoString())
+			}
+
+			wantCalls := 0
+			if tc.computed {
+				wantCalls = 1
+			}
+			assert.EqualInts(t, wantCalls, calls)
+		})
+	}
+}
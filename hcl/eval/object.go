@@ -125,7 +125,7 @@ func (obj *Object) SetFromCtyValues(values map[string]cty.Value, origin Info) *O
 	for k, v := range values {
 		if v.Type().IsObjectType() {
 			subtree := NewObject(origin)
-			subtree.SetFromCtyValues(v.AsValueMap(), origin)
+			subtree.SetFromCtyValues(valueMap(v), origin)
 			obj.Set(k, subtree)
 		} else {
 			obj.Set(k, NewCtyValue(v, origin))
@@ -302,9 +302,9 @@ func (obj *Object) AsValueMap() map[string]cty.Value {
 	return vmap
 }
 
-// String representation of the object.
+// String representation of the object, with the sensitive values redacted.
 func (obj *Object) String() string {
-	return fmt.FormatAttributes(obj.AsValueMap())
+	return fmt.FormatAttributes(RedactMap(obj.AsValueMap()))
 }
 
 // NewCtyValue creates a new cty.Value wrapper.
@@ -323,7 +323,7 @@ func NewCtyValue(val cty.Value, origin Info) CtyValue {
 func NewValue(val cty.Value, origin Info) Value {
 	if val.Type().IsObjectType() {
 		obj := NewObject(origin)
-		obj.SetFromCtyValues(val.AsValueMap(), origin)
+		obj.SetFromCtyValues(valueMap(val), origin)
 		return obj
 	}
 	return NewCtyValue(val, origin)
@@ -335,8 +335,12 @@ func (v CtyValue) Info() Info { return v.origin }
 // IsObject returns false for CtyValue values.
 func (v CtyValue) IsObject() bool { return false }
 
-// Raw returns the original cty.Value value (unmarked).
+// Raw returns the original cty.Value value, unmarked except for the
+// sensitive mark.
 func (v CtyValue) Raw() cty.Value {
-	val, _ := v.Value.Unmark()
+	val, marks := v.Value.Unmark()
+	if _, ok := marks[SensitiveMark]; ok {
+		val = val.Mark(SensitiveMark)
+	}
 	return val
 }
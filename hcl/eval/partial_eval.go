@@ -81,8 +81,8 @@ func (c *Context) partialEvalTmplWrap(wrap *hclsyntax.TemplateWrapExpr) (hhcl.Ex
 	}
 	if v, ok := newwrap.(*hclsyntax.LiteralValueExpr); ok {
 		// TODO(fix)
-		if v.Val.Type() == cty.String && strings.Contains(v.Val.AsString(), "${") {
-			panic(v.Val.AsString())
+		if val, _ := v.Val.Unmark(); val.Type() == cty.String && strings.Contains(val.AsString(), "${") {
+			panic(val.AsString())
 		}
 		return v, nil
 	}
@@ -121,7 +121,7 @@ func (c *Context) partialEvalObject(obj *hclsyntax.ObjectConsExpr) (hclsyntax.Ex
 
 func (c *Context) partialEvalFunc(funcall *hclsyntax.FunctionCallExpr) (hhcl.Expression, error) {
 	if strings.HasPrefix(funcall.Name, "tm_") {
-		val, err := c.Eval(funcall)
+		val, err := c.evalPartial(funcall)
 		if err != nil {
 			return nil, err
 		}
@@ -156,7 +156,7 @@ func (c *Context) partialEvalIndex(index *hclsyntax.IndexExpr) (hhcl.Expression,
 		return index, nil
 	}
 
-	val, err := c.Eval(index)
+	val, err := c.evalPartial(index)
 	if err != nil {
 		return nil, err
 	}
@@ -178,7 +178,7 @@ func (c *Context) partialEvalSplat(expr *hclsyntax.SplatExpr) (hhcl.Expression,
 	if c.hasUnknownVars(expr.Each) {
 		return expr, nil
 	}
-	val, err := c.Eval(expr)
+	val, err := c.evalPartial(expr)
 	if err != nil {
 		// this can happen in the case of using funcalls not prefixed with tm_
 		return expr, nil
@@ -189,6 +189,16 @@ func (c *Context) partialEvalSplat(expr *hclsyntax.SplatExpr) (hhcl.Expression,
 	}, nil
 }
 
+// evalPartial evaluates the fully known parts of partially evaluated
+// expressions, keeping the sensitive marks if the context was created by
+// PartialEvalSensitive.
+func (c *Context) evalPartial(expr hhcl.Expression) (cty.Value, error) {
+	if c.keepSensitive {
+		return c.EvalSensitive(expr)
+	}
+	return c.Eval(expr)
+}
+
 func (c *Context) hasUnknownVars(expr hclsyntax.Expression) bool {
 	for _, namespace := range expr.Variables() {
 		if !c.HasNamespace(namespace.RootName()) {
@@ -317,7 +327,7 @@ func (c *Context) partialEvalScopeTrav(scope *hclsyntax.ScopeTraversalExpr, opts
 		return scope, nil
 	}
 
-	val, err := c.Eval(scope)
+	val, err := c.evalPartial(scope)
 	if err != nil {
 		return nil, err
 	}
@@ -364,7 +374,7 @@ func (c *Context) partialEvalRelTrav(rel *hclsyntax.RelativeTraversalExpr) (hhcl
 		return rel, nil
 	}
 
-	val, err := c.Eval(rel)
+	val, err := c.evalPartial(rel)
 	if err != nil {
 		return nil, err
 	}
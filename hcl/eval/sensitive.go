@@ -0,0 +1,110 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package eval
+
+import (
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+type sensitiveMark string
+
+// SensitiveMark is the cty mark of sensitive values.
+// Sensitive values are redacted by the CLI outputs but their real values are
+// used by code generation and when running commands.
+const SensitiveMark = sensitiveMark("sensitive")
+
+// RedactedValue is the placeholder shown in place of sensitive values.
+const RedactedValue = "(sensitive)"
+
+// IsSensitive tells if the value, or any value nested inside it, is sensitive.
+func IsSensitive(val cty.Value) bool {
+	if !val.ContainsMarked() {
+		return false
+	}
+	_, marks := val.UnmarkDeep()
+	_, ok := marks[SensitiveMark]
+	return ok
+}
+
+// Redact returns the value with every sensitive value nested inside it
+// replaced by the RedactedValue placeholder. The returned value has no marks,
+// but its type may differ from the original value, then it must only be used
+// for displaying purposes.
+func Redact(val cty.Value) cty.Value {
+	val, marks := val.Unmark()
+	if _, ok := marks[SensitiveMark]; ok {
+		return cty.StringVal(RedactedValue)
+	}
+	if val.IsNull() || !val.IsKnown() || !val.ContainsMarked() {
+		val, _ = val.UnmarkDeep()
+		return val
+	}
+
+	typ := val.Type()
+	switch {
+	case typ.IsObjectType() || typ.IsMapType():
+		return cty.ObjectVal(RedactMap(val.AsValueMap()))
+	case typ.IsListType() || typ.IsSetType() || typ.IsTupleType():
+		elems := []cty.Value{}
+		for it := val.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			elems = append(elems, Redact(elem))
+		}
+		return cty.TupleVal(elems)
+	}
+	val, _ = val.UnmarkDeep()
+	return val
+}
+
+// RedactMap returns a copy of the values map with the sensitive values
+// redacted. See Redact.
+func RedactMap(values map[string]cty.Value) map[string]cty.Value {
+	redacted := make(map[string]cty.Value, len(values))
+	for name, val := range values {
+		redacted[name] = Redact(val)
+	}
+	return redacted
+}
+
+// RedactExpr redacts the sensitive values embedded in the expression, like
+// the ones resulting from a partial evaluation. The literal values of the
+// expression are redacted in place. See Redact.
+func RedactExpr(expr hhcl.Expression) hhcl.Expression {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return expr
+	}
+	_ = hclsyntax.VisitAll(node, func(node hclsyntax.Node) hhcl.Diagnostics {
+		if lit, ok := node.(*hclsyntax.LiteralValueExpr); ok && lit.Val.ContainsMarked() {
+			lit.Val = Redact(lit.Val)
+		}
+		return nil
+	})
+	return expr
+}
+
+// valueMap returns the attributes of the object value, propagating its
+// sensitive mark to each attribute.
+func valueMap(val cty.Value) map[string]cty.Value {
+	val, marks := val.Unmark()
+	values := val.AsValueMap()
+	if _, ok := marks[SensitiveMark]; !ok {
+		return values
+	}
+	for name, attr := range values {
+		values[name] = attr.Mark(SensitiveMark)
+	}
+	return values
+}
+
+// unmarkSensitive returns the value without sensitive marks.
+func unmarkSensitive(val cty.Value) cty.Value {
+	if !val.ContainsMarked() {
+		return val
+	}
+	val, _ = val.UnmarkDeep()
+	return val
+}
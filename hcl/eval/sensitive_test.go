@@ -0,0 +1,132 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package eval_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclwrite"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/test"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSensitiveValues(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		expr      string
+		sensitive bool
+		want      cty.Value
+		redacted  cty.Value
+	}
+
+	for _, tc := range []testcase{
+		{
+			expr:     `"plain"`,
+			want:     cty.StringVal("plain"),
+			redacted: cty.StringVal("plain"),
+		},
+		{
+			expr:      `tm_sensitive("secret")`,
+			sensitive: true,
+			want:      cty.StringVal("secret"),
+			redacted:  cty.StringVal(eval.RedactedValue),
+		},
+		{
+			expr:      `"user:${tm_sensitive("secret")}"`,
+			sensitive: true,
+			want:      cty.StringVal("user:secret"),
+			redacted:  cty.StringVal(eval.RedactedValue),
+		},
+		{
+			expr:      `tm_upper(tm_sensitive("secret"))`,
+			sensitive: true,
+			want:      cty.StringVal("SECRET"),
+			redacted:  cty.StringVal(eval.RedactedValue),
+		},
+		{
+			expr:      `[1, tm_sensitive(2)]`,
+			sensitive: true,
+			want:      cty.TupleVal([]cty.Value{cty.NumberIntVal(1), cty.NumberIntVal(2)}),
+			redacted: cty.TupleVal([]cty.Value{
+				cty.NumberIntVal(1), cty.StringVal(eval.RedactedValue),
+			}),
+		},
+		{
+			expr:      `{ a = 1, b = tm_sensitive("b") }`,
+			sensitive: true,
+			want: cty.ObjectVal(map[string]cty.Value{
+				"a": cty.NumberIntVal(1),
+				"b": cty.StringVal("b"),
+			}),
+			redacted: cty.ObjectVal(map[string]cty.Value{
+				"a": cty.NumberIntVal(1),
+				"b": cty.StringVal(eval.RedactedValue),
+			}),
+		},
+		{
+			expr:     `tm_nonsensitive(tm_sensitive("secret"))`,
+			want:     cty.StringVal("secret"),
+			redacted: cty.StringVal("secret"),
+		},
+	} {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := eval.NewContext(stdlib.Functions(test.TempDir(t)))
+			expr, err := ast.ParseExpression(tc.expr, "test.hcl")
+			assert.NoError(t, err)
+
+			val, err := ctx.EvalSensitive(expr)
+			assert.NoError(t, err)
+			assert.IsTrue(t, eval.IsSensitive(val) == tc.sensitive,
+				"IsSensitive(%s) != %t", tc.expr, tc.sensitive)
+
+			redacted := eval.Redact(val)
+			assert.IsTrue(t, redacted.RawEquals(tc.redacted),
+				"redacted %#v != %#v", redacted, tc.redacted)
+
+			val, err = ctx.Eval(expr)
+			assert.NoError(t, err)
+			assert.IsTrue(t, !val.ContainsMarked())
+			assert.IsTrue(t, val.RawEquals(tc.want), "eval %#v != %#v", val, tc.want)
+		})
+	}
+}
+
+func TestSensitivePartialEvalRedacted(t *testing.T) {
+	t.Parallel()
+
+	for exprStr, want := range map[string]string{
+		`global.token`:                   `"(sensitive)"`,
+		`"user:${global.token}"`:         `"user:(sensitive)"`,
+		`[global.token, global.plain]`:   `["(sensitive)", "plain"]`,
+		`tm_upper(tm_sensitive("abc"))`:  `"(sensitive)"`,
+		`{ a = global.token, b = "b" }`:  "{\n  a = \"(sensitive)\"\n  b = \"b\"\n}",
+		`"${global.plain}-${unknown.x}"`: `"plain-${unknown.x}"`,
+	} {
+		ctx := eval.NewContext(stdlib.Functions(test.TempDir(t)))
+		ctx.SetNamespace("global", map[string]cty.Value{
+			"token": cty.StringVal("s3cr3t").Mark(eval.SensitiveMark),
+			"plain": cty.StringVal("plain"),
+		})
+		expr, err := ast.ParseExpression(exprStr, "test.hcl")
+		assert.NoError(t, err)
+
+		newexpr, err := ctx.PartialEvalSensitive(expr)
+		assert.NoError(t, err)
+
+		got := string(hclwrite.Format(ast.TokensForExpression(eval.RedactExpr(newexpr)).Bytes()))
+		assert.IsTrue(t, !strings.Contains(got, "s3cr3t") && !strings.Contains(got, "ABC"),
+			"%s: sensitive value leaked: %s", exprStr, got)
+		assert.EqualStrings(t, want, got, "partial eval of %s", exprStr)
+	}
+}
@@ -83,6 +83,11 @@ func FormatTree(dir string) ([]FormatResult, error) {
 			Str("file", f).
 			Logger()
 
+		if fs.IsTerramateJSONFile(f) {
+			logger.Trace().Msg("ignoring JSON file")
+			continue
+		}
+
 		logger.Trace().Msg("reading file")
 
 		path := filepath.Join(dir, f)
@@ -0,0 +1,159 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"sort"
+	"strings"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// FunctionBlockType is the name of the block defining a user function.
+const FunctionBlockType = "function"
+
+// FunctionPrefix is the prefix of the name of the user functions in the
+// evaluation context, then the function "name" is called as tm_fn_name().
+const FunctionPrefix = "tm_fn_"
+
+// FunctionConfig represents a function block, which defines a user function
+// available to the directory and its subdirectories.
+type FunctionConfig struct {
+	// Range is the range of the whole block.
+	Range info.Range
+
+	// Name is the function name, as given by the block label.
+	Name string
+
+	// Params are the names of the function parameters, in order.
+	Params []string
+
+	// Result is the expression computing the function result. It can only
+	// reference the parameters and call other functions.
+	Result hhcl.Expression
+}
+
+// FuncName returns the name of the function in the evaluation context.
+func (f FunctionConfig) FuncName() string { return FunctionPrefix + f.Name }
+
+func parseFunctionBlock(block *ast.Block) (FunctionConfig, error) {
+	cfg := FunctionConfig{
+		Range: block.Range,
+	}
+	errs := errors.L()
+
+	if len(block.Labels) != 1 {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"function must have a single label with the function name"))
+	} else {
+		cfg.Name = block.Labels[0]
+		if !hclsyntax.ValidIdentifier(cfg.Name) {
+			errs.Append(errors.E(ErrTerramateSchema, block.Block.LabelRanges[0],
+				"function name %q is not a valid identifier", cfg.Name))
+		}
+	}
+
+	for _, subBlock := range block.Blocks {
+		errs.Append(errors.E(ErrTerramateSchema, subBlock.DefRange(),
+			"unrecognized block function.%s", subBlock.Type))
+	}
+
+	for _, attr := range block.Attributes.SortedList() {
+		switch attr.Name {
+		case "params":
+			params, err := parseFunctionParams(attr)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			cfg.Params = params
+		case "result":
+			cfg.Result = attr.Expr
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute function.%s", attr.Name))
+		}
+	}
+
+	if cfg.Result == nil {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"function must have the result attribute"))
+	}
+
+	if err := errs.AsError(); err != nil {
+		return FunctionConfig{}, err
+	}
+
+	params := map[string]struct{}{}
+	for _, param := range cfg.Params {
+		params[param] = struct{}{}
+	}
+	for _, traversal := range cfg.Result.Variables() {
+		if _, ok := params[traversal.RootName()]; !ok {
+			errs.Append(errors.E(ErrTerramateSchema, traversal.SourceRange(),
+				"function %q result can only reference its parameters (%s) but references %q",
+				cfg.Name, strings.Join(cfg.Params, ", "), traversal.RootName()))
+		}
+	}
+	if err := errs.AsError(); err != nil {
+		return FunctionConfig{}, err
+	}
+	return cfg, nil
+}
+
+func parseFunctionParams(attr ast.Attribute) ([]string, error) {
+	exprs, diags := hhcl.ExprList(attr.Expr)
+	if diags.HasErrors() {
+		return nil, attrErr(attr, "function.params must be a list of parameter names")
+	}
+	errs := errors.L()
+	seen := map[string]struct{}{}
+	var params []string
+	for _, expr := range exprs {
+		traversal, diags := hhcl.AbsTraversalForExpr(expr)
+		if diags.HasErrors() || len(traversal) != 1 {
+			errs.Append(errors.E(ErrTerramateSchema, expr.Range(),
+				"function.params must only have parameter names"))
+			continue
+		}
+		name := traversal.RootName()
+		if _, ok := seen[name]; ok {
+			errs.Append(errors.E(ErrTerramateSchema, expr.Range(),
+				"function parameter %q is duplicated", name))
+			continue
+		}
+		seen[name] = struct{}{}
+		params = append(params, name)
+	}
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// FunctionCalls returns the sorted names of the functions called by the
+// expression.
+func FunctionCalls(expr hhcl.Expression) []string {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return nil
+	}
+	seen := map[string]struct{}{}
+	_ = hclsyntax.VisitAll(node, func(node hclsyntax.Node) hhcl.Diagnostics {
+		if call, ok := node.(*hclsyntax.FunctionCallExpr); ok {
+			seen[call.Name] = struct{}{}
+		}
+		return nil
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
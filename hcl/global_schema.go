@@ -0,0 +1,170 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GlobalSchemaConfig represents a parsed global_schema block.
+type GlobalSchemaConfig struct {
+	// Range is the range of the entire block definition.
+	Range info.Range
+
+	// Path is the global accessor path declared by the block label.
+	// Eg.: the label "network.cidr" declares the global.network.cidr.
+	Path []string
+
+	// Type is the type constraint of the global.
+	Type cty.Type
+
+	// Required tells if the global must be defined.
+	Required bool
+
+	// Description is the description of the global.
+	Description string
+
+	// Validations are the custom validation rules of the global.
+	Validations []GlobalValidationConfig
+}
+
+// GlobalValidationConfig represents a validation block of a global_schema.
+type GlobalValidationConfig struct {
+	// Range is the range of the entire block definition.
+	Range info.Range
+
+	// Condition must evaluate to true for valid globals.
+	Condition hcl.Expression
+
+	// ErrorMessage is the message reported when the condition is false.
+	ErrorMessage hcl.Expression
+}
+
+// Name returns the global name (the path joined by dots).
+func (s GlobalSchemaConfig) Name() string {
+	return strings.Join(s.Path, ".")
+}
+
+func parseGlobalSchemaBlock(block *ast.Block) (GlobalSchemaConfig, error) {
+	cfg := GlobalSchemaConfig{
+		Range: block.Range,
+		Type:  cty.DynamicPseudoType,
+	}
+	errs := errors.L()
+
+	if len(block.Labels) != 1 {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"global_schema must have a single label with the global name"))
+	} else {
+		cfg.Path = strings.Split(block.Labels[0], ".")
+		for _, name := range cfg.Path {
+			if !hclsyntax.ValidIdentifier(name) {
+				errs.Append(errors.E(ErrTerramateSchema, block.Block.LabelRanges[0],
+					"global_schema label %q is not a valid global path", block.Labels[0]))
+				break
+			}
+		}
+	}
+
+	for _, attr := range block.Attributes.SortedList() {
+		switch attr.Name {
+		case "type":
+			typ, diags := typeexpr.TypeConstraint(attr.Expr)
+			if diags.HasErrors() {
+				errs.Append(errors.E(ErrTerramateSchema, diags))
+				continue
+			}
+			cfg.Type = typ
+		case "required":
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(ErrTerramateSchema, diags))
+				continue
+			}
+			if val.Type() != cty.Bool || val.IsNull() {
+				errs.Append(attrErr(attr, "global_schema.required must be a boolean"))
+				continue
+			}
+			cfg.Required = val.True()
+		case "description":
+			val, diags := attr.Expr.Value(nil)
+			if diags.HasErrors() {
+				errs.Append(errors.E(ErrTerramateSchema, diags))
+				continue
+			}
+			if val.Type() != cty.String || val.IsNull() {
+				errs.Append(attrErr(attr, "global_schema.description must be a string"))
+				continue
+			}
+			cfg.Description = val.AsString()
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute %s.%s", block.Type, attr.Name,
+			))
+		}
+	}
+
+	for _, subBlock := range block.Blocks {
+		if subBlock.Type != "validation" {
+			errs.Append(errors.E(ErrTerramateSchema, subBlock.DefRange(),
+				"unrecognized block %s.%s", block.Type, subBlock.Type))
+			continue
+		}
+		validation, err := parseGlobalValidationBlock(subBlock)
+		errs.Append(err)
+		if err == nil {
+			cfg.Validations = append(cfg.Validations, validation)
+		}
+	}
+
+	if err := errs.AsError(); err != nil {
+		return GlobalSchemaConfig{}, err
+	}
+	return cfg, nil
+}
+
+func parseGlobalValidationBlock(block *ast.Block) (GlobalValidationConfig, error) {
+	cfg := GlobalValidationConfig{
+		Range: block.Range,
+	}
+	errs := errors.L()
+	errs.Append(checkNoLabels(block))
+	errs.Append(checkHasSubBlocks(block))
+
+	for _, attr := range block.Attributes {
+		switch attr.Name {
+		case "condition":
+			cfg.Condition = attr.Expr
+		case "error_message":
+			cfg.ErrorMessage = attr.Expr
+		default:
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute validation.%s", attr.Name,
+			))
+		}
+	}
+
+	if cfg.Condition == nil {
+		errs.Append(errors.E(ErrTerramateSchema, block.Range,
+			"validation.condition is required"))
+	}
+
+	if cfg.ErrorMessage == nil {
+		errs.Append(errors.E(ErrTerramateSchema, block.Range,
+			"validation.error_message is required"))
+	}
+
+	if err := errs.AsError(); err != nil {
+		return GlobalValidationConfig{}, err
+	}
+	return cfg, nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/terramate-io/terramate/project"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// GlobalsFileBlockType is the name of the block loading globals from a data
+// file.
+const GlobalsFileBlockType = "globals_file"
+
+// GlobalsFileConfig represents a globals_file block, which loads globals from
+// a YAML or JSON data file.
+type GlobalsFileConfig struct {
+	// Range is the range of the whole block.
+	Range info.Range
+
+	// Labels is the global path where the file content is loaded. If empty,
+	// the top-level keys of the file are loaded as globals.
+	Labels []string
+
+	// Path is the project path of the data file.
+	Path project.Path
+}
+
+func (p *TerramateParser) parseGlobalsFileBlock(block *ast.Block) (GlobalsFileConfig, error) {
+	cfg := GlobalsFileConfig{
+		Range:  block.Range,
+		Labels: block.Labels,
+	}
+	errs := errors.L()
+
+	if len(block.Labels) > project.MaxGlobalLabels {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"globals_file supports at most %d labels", project.MaxGlobalLabels))
+	}
+	for i, label := range block.Labels {
+		if !hclsyntax.ValidIdentifier(label) {
+			errs.Append(errors.E(ErrTerramateSchema, block.Block.LabelRanges[i],
+				"globals_file label %q is not a valid identifier", label))
+		}
+	}
+
+	for _, subBlock := range block.Blocks {
+		errs.Append(errors.E(ErrTerramateSchema, subBlock.DefRange(),
+			"unrecognized block globals_file.%s", subBlock.Type))
+	}
+
+	pathAttr, ok := block.Attributes["path"]
+	if !ok {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"globals_file must have the path attribute"))
+	}
+
+	for _, attr := range block.Attributes.SortedList() {
+		if attr.Name != "path" {
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute globals_file.%s", attr.Name))
+		}
+	}
+
+	if err := errs.AsError(); err != nil {
+		return GlobalsFileConfig{}, err
+	}
+
+	val, err := p.evalctx.Eval(pathAttr.Expr)
+	if err != nil {
+		return GlobalsFileConfig{}, errors.E(ErrTerramateSchema, err,
+			"failed to evaluate globals_file.path attribute")
+	}
+	if val.Type() != cty.String || val.IsNull() {
+		return GlobalsFileConfig{}, attrErr(pathAttr,
+			"globals_file.path must be a string but got %s", val.Type().FriendlyName())
+	}
+
+	datapath := val.AsString()
+	var abspath string
+	if path.IsAbs(datapath) {
+		abspath = filepath.Join(p.rootdir, filepath.FromSlash(datapath))
+	} else {
+		abspath = filepath.Join(p.dir, filepath.FromSlash(datapath))
+	}
+	if abspath != p.rootdir && !strings.HasPrefix(abspath, p.rootdir+string(filepath.Separator)) {
+		return GlobalsFileConfig{}, attrErr(pathAttr,
+			"globals_file.path %q is outside the project", datapath)
+	}
+
+	cfg.Path = project.PrjAbsPath(p.rootdir, abspath)
+	return cfg, nil
+}
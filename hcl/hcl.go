@@ -8,6 +8,7 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -15,12 +16,14 @@ import (
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config/tag"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/fs"
 	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/hcl/info"
 	"github.com/terramate-io/terramate/stdlib"
+	"github.com/terramate-io/terramate/tf"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -29,6 +32,7 @@ const (
 	ErrHCLSyntax           errors.Kind = "HCL syntax error"
 	ErrTerramateSchema     errors.Kind = "terramate schema error"
 	ErrImport              errors.Kind = "import error"
+	ErrImportNotFetched    errors.Kind = "remote import not fetched"
 	ErrUnexpectedTerramate errors.Kind = "`terramate` block is only allowed at the project root directory"
 )
 
@@ -46,8 +50,33 @@ type Config struct {
 	Asserts   []AssertConfig
 	Generate  GenerateConfig
 
+	// GlobalSchemas are the global declarations of this configuration.
+	GlobalSchemas []GlobalSchemaConfig
+
+	// GlobalsFiles are the data files loaded as globals by this configuration.
+	GlobalsFiles []GlobalsFileConfig
+
+	// Functions are the user functions defined by this configuration.
+	Functions []FunctionConfig
+
+	// StackDefaults are the defaults inherited by the stacks of this
+	// directory and its subdirectories, or nil if not declared.
+	StackDefaults *StackDefaultsConfig
+
+	// RunEnv is the run.env block of this directory, inherited by the stacks
+	// of the directory and its subdirectories, or nil if not declared.
+	RunEnv *RunEnv
+
 	Imported RawConfig
 
+	// ImportedFiles is the list of files imported by this configuration,
+	// directly or through nested imports.
+	ImportedFiles []string
+
+	// ImportedBy maps each file of ImportedFiles to the file with the import
+	// block importing it.
+	ImportedBy map[string]string
+
 	// absdir is the absolute path to the configuration directory.
 	absdir string
 }
@@ -109,11 +138,34 @@ type CloudConfig struct {
 	Organization string
 }
 
+// StacksConfig represents the policies enforced on all the stacks of the
+// project. The ranges tell where each policy was declared.
+type StacksConfig struct {
+	// AllowedTags is the set of tags the stacks are allowed to have. If empty,
+	// any tag is allowed.
+	AllowedTags      []string
+	AllowedTagsRange info.Range
+
+	// RequiredTags is the set of tags every stack must have.
+	RequiredTags      []string
+	RequiredTagsRange info.Range
+
+	// NamePattern is the regular expression the stack names must match, or nil
+	// if any name is allowed.
+	NamePattern      *regexp.Regexp
+	NamePatternRange info.Range
+
+	// RequireID tells if every stack must have an ID.
+	RequireID      bool
+	RequireIDRange info.Range
+}
+
 // RootConfig represents the root config block of a Terramate configuration.
 type RootConfig struct {
-	Git   *GitConfig
-	Run   *RunConfig
-	Cloud *CloudConfig
+	Git    *GitConfig
+	Run    *RunConfig
+	Cloud  *CloudConfig
+	Stacks *StacksConfig
 }
 
 // ManifestDesc represents a parsed manifest description.
@@ -153,6 +205,9 @@ type Terramate struct {
 
 // Stack is the parsed "stack" HCL block.
 type Stack struct {
+	// Range is the range of the entire block definition.
+	Range info.Range
+
 	// ID of the stack. If the ID is empty it indicates this stack has no ID.
 	ID string
 
@@ -183,6 +238,26 @@ type Stack struct {
 
 	// Watch is a list of files to be watched for changes.
 	Watch []string
+
+	// Matrix maps the matrix keys to their list of values. If set, the stack
+	// is a template which is expanded into a stack for each combination of
+	// the values.
+	Matrix map[string][]string
+
+	// Each is the combination of matrix values of a stack created from a
+	// stack matrix, or nil if the stack was not created from a matrix.
+	Each map[string]string
+
+	// Condition is the unevaluated expression telling if the stack is
+	// enabled, or nil if the stack is always enabled.
+	Condition hcl.Expression
+
+	// InheritedTags are the tags inherited from the stack_defaults blocks
+	// of the parent directories, outermost first.
+	InheritedTags []InheritedTag
+
+	// AttrRanges maps the names of the stack attributes to their ranges.
+	AttrRanges map[string]info.Range
 }
 
 // GenHCLBlock represents a parsed generate_hcl block.
@@ -254,6 +329,25 @@ type TerramateParser struct {
 	// parsedFiles stores a map of all parsed files
 	parsedFiles map[string]parsedFile
 
+	// importedFiles stores the files imported by this parser, including the
+	// ones imported by its sub-parsers.
+	importedFiles []string
+
+	// importedBy maps the imported files to the files importing them.
+	importedBy map[string]string
+
+	// importRuntime is the terramate metadata available to import blocks.
+	// Imported files share the runtime of the importing configuration.
+	importRuntime map[string]cty.Value
+
+	// inheritedTags are the tags inherited by the directory from the
+	// stack_defaults blocks of its parent directories.
+	inheritedTags []InheritedTag
+
+	// fetchImports tells if the remote import sources which are not cached
+	// yet are fetched.
+	fetchImports bool
+
 	strict bool
 	// if true, calling Parse() or MinimalParse() will fail.
 	parsed bool
@@ -310,6 +404,7 @@ func NewTerramateParser(rootdir string, dir string) (*TerramateParser, error) {
 		Config:      NewTopLevelRawConfig(),
 		Imported:    NewTopLevelRawConfig(),
 		parsedFiles: make(map[string]parsedFile),
+		importedBy:  map[string]string{},
 		evalctx:     eval.NewContext(stdlib.Functions(dir)),
 	}, nil
 }
@@ -334,8 +429,8 @@ func (p *TerramateParser) addParsedFile(origin string, kind parsedKind, files ..
 	}
 }
 
-// AddDir walks over all the files in the directory dir and add all .tm and
-// .tm.hcl files to the parser.
+// AddDir walks over all the files in the directory dir and add all .tm,
+// .tm.hcl and .tm.json files to the parser.
 func (p *TerramateParser) AddDir(dir string) error {
 	logger := log.With().
 		Str("action", "parser.AddDir()").
@@ -485,6 +580,14 @@ func (p *TerramateParser) parseSyntax() error {
 	errs := errors.L()
 	for _, name := range p.sortedFilenames() {
 		data := p.files[name]
+		if fs.IsTerramateJSONFile(name) {
+			if err := p.parseJSONFile(name, data); err != nil {
+				errs.Append(err)
+				continue
+			}
+			p.addParsedFile(p.dir, internal, name)
+			continue
+		}
 		_, diags := p.hclparser.ParseHCL(data, name)
 		if diags.HasErrors() {
 			errs.Append(errors.E(ErrHCLSyntax, diags))
@@ -509,41 +612,81 @@ func (p *TerramateParser) applyImports() error {
 }
 
 func (p *TerramateParser) handleImport(importBlock *ast.Block) error {
+	if condAttr, ok := importBlock.Attributes["condition"]; ok {
+		condVal, err := p.evalImportAttr(condAttr)
+		if err != nil {
+			return err
+		}
+		if condVal.Type() != cty.Bool || condVal.IsNull() || !condVal.IsKnown() {
+			return attrErr(condAttr, "import.condition must be a non-null boolean")
+		}
+		if condVal.False() {
+			return nil
+		}
+	}
+
 	srcAttr := importBlock.Attributes["source"]
-	srcVal, diags := srcAttr.Expr.Value(nil)
-	if diags.HasErrors() {
-		return errors.E(ErrTerramateSchema, srcAttr.Expr.Range(),
-			"failed to evaluate import.source")
+	srcVal, err := p.evalImportAttr(srcAttr)
+	if err != nil {
+		return err
 	}
 
-	if srcVal.Type() != cty.String {
-		return attrErr(srcAttr, "import.source must be a string")
+	if srcVal.Type() != cty.String || srcVal.IsNull() || !srcVal.IsKnown() {
+		return attrErr(srcAttr, "import.source must be a non-null string")
 	}
 
+	var matches []string
 	src := srcVal.AsString()
-	srcBase := path.Base(src)
-	srcDir := path.Dir(src)
-	if path.IsAbs(srcDir) { // project-path
-		srcDir = filepath.Join(p.rootdir, srcDir)
+	if IsRemoteImport(src) {
+		modsrc, err := tf.ParseSource(src)
+		if err != nil {
+			return errors.E(ErrImport, srcAttr.Expr.Range(), err,
+				"invalid remote import source")
+		}
+		if modsrc.Subdir == "" {
+			return errors.E(ErrImport, srcAttr.Expr.Range(),
+				"remote import source %q must select files with the //path syntax", src)
+		}
+
+		remoteImportDir := RemoteImportDir
+		if p.fetchImports {
+			remoteImportDir = FetchRemoteImport
+		}
+		srcDir, err := remoteImportDir(p.rootdir, modsrc)
+		if err != nil {
+			return errors.E(srcAttr.Expr.Range(), err)
+		}
+
+		matches, err = filepath.Glob(filepath.Join(srcDir, filepath.FromSlash(modsrc.Subdir)))
+		if err != nil {
+			return errors.E(ErrTerramateSchema, srcAttr.Expr.Range(),
+				"failed to evaluate import.source")
+		}
 	} else {
-		srcDir = filepath.Join(p.dir, srcDir)
-	}
+		srcBase := path.Base(src)
+		srcDir := path.Dir(src)
+		if path.IsAbs(srcDir) { // project-path
+			srcDir = filepath.Join(p.rootdir, srcDir)
+		} else {
+			srcDir = filepath.Join(p.dir, srcDir)
+		}
 
-	if srcDir == p.dir {
-		return errors.E(ErrImport, srcAttr.Expr.Range(),
-			"importing files in the same directory is not permitted")
-	}
+		if srcDir == p.dir {
+			return errors.E(ErrImport, srcAttr.Expr.Range(),
+				"importing files in the same directory is not permitted")
+		}
 
-	if strings.HasPrefix(p.dir, srcDir) {
-		return errors.E(ErrImport, srcAttr.Expr.Range(),
-			"importing files in the same tree is not permitted")
-	}
+		if strings.HasPrefix(p.dir, srcDir) {
+			return errors.E(ErrImport, srcAttr.Expr.Range(),
+				"importing files in the same tree is not permitted")
+		}
 
-	src = filepath.Join(srcDir, srcBase)
-	matches, err := filepath.Glob(src)
-	if err != nil {
-		return errors.E(ErrTerramateSchema, srcAttr.Expr.Range(),
-			"failed to evaluate import.source")
+		var err error
+		matches, err = filepath.Glob(filepath.Join(srcDir, srcBase))
+		if err != nil {
+			return errors.E(ErrTerramateSchema, srcAttr.Expr.Range(),
+				"failed to evaluate import.source")
+		}
 	}
 	if matches == nil {
 		return errors.E(ErrImport, srcAttr.Expr.Range(),
@@ -587,6 +730,8 @@ func (p *TerramateParser) handleImport(importBlock *ast.Block) error {
 				err)
 		}
 		importParser.addParsedFile(p.dir, external, p.internalParsedFiles()...)
+		importParser.importRuntime = p.importRuntime
+		importParser.fetchImports = p.fetchImports
 		err = importParser.Parse()
 		if err != nil {
 			return err
@@ -607,10 +752,24 @@ func (p *TerramateParser) handleImport(importBlock *ast.Block) error {
 		}
 
 		p.addParsedFile(p.dir, external, file)
+		p.importedFiles = append(p.importedFiles, file)
+		p.importedFiles = append(p.importedFiles, importParser.importedFiles...)
+		p.importedBy[file] = importBlock.Range.HostPath()
+		for imported, importer := range importParser.importedBy {
+			p.importedBy[imported] = importer
+		}
 	}
 	return nil
 }
 
+// ImportedFiles returns the files imported by the parsed configuration,
+// directly or through nested imports. It must be called after Parse().
+func (p *TerramateParser) ImportedFiles() []string {
+	files := append([]string{}, p.importedFiles...)
+	sort.Strings(files)
+	return files
+}
+
 func (p *TerramateParser) sortedFilenames() []string {
 	filenames := []string{}
 	for fname := range p.files {
@@ -643,19 +802,43 @@ func (p *TerramateParser) parseStack(stackblock *ast.Block) (*Stack, error) {
 		Logger()
 
 	errs := errors.L()
+	stack := &Stack{
+		Range:      stackblock.Range,
+		AttrRanges: map[string]info.Range{},
+	}
 	for _, block := range stackblock.Body.Blocks {
-		errs.Append(
-			errors.E(block.TypeRange, "unrecognized block %q", block.Type),
-		)
+		if block.Type != StackMatrixBlockType {
+			errs.Append(
+				errors.E(block.TypeRange, "unrecognized block %q", block.Type),
+			)
+			continue
+		}
+		if stack.Matrix != nil {
+			errs.Append(errors.E(ErrTerramateSchema, block.TypeRange,
+				"multiple stack.matrix blocks"))
+			continue
+		}
+		matrix, err := p.parseStackMatrix(block)
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+		stack.Matrix = matrix
 	}
 
-	stack := &Stack{}
-
 	logger.Debug().Msg("Get stack attributes.")
 	attrs := ast.AsHCLAttributes(stackblock.Body.Attributes)
 	for _, attr := range ast.SortRawAttributes(attrs) {
 		logger.Trace().Msg("Get attribute value.")
 
+		stack.AttrRanges[attr.Name] = info.NewRange(p.rootdir, attr.Range)
+
+		if attr.Name == "condition" {
+			// evaluated with the stack globals and metadata.
+			stack.Condition = attr.Expr
+			continue
+		}
+
 		attrVal, err := p.evalctx.Eval(attr.Expr)
 		if err != nil {
 			errs.Append(
@@ -773,13 +956,15 @@ func (c Config) AbsDir() string { return c.absdir }
 func (c Config) IsEmpty() bool {
 	return c.Stack == nil && c.Terramate == nil &&
 		c.Vendor == nil && len(c.Asserts) == 0 &&
-		len(c.Globals) == 0 &&
+		len(c.Globals) == 0 && len(c.GlobalSchemas) == 0 &&
+		len(c.GlobalsFiles) == 0 && len(c.Functions) == 0 &&
+		c.StackDefaults == nil && c.RunEnv == nil &&
 		len(c.Generate.Files) == 0 && len(c.Generate.HCLs) == 0
 }
 
 // HasGlobals tells if the configuration has any globals defined.
 func (c Config) HasGlobals() bool {
-	return len(c.Globals) > 0
+	return len(c.Globals) > 0 || len(c.GlobalsFiles) > 0
 }
 
 // Save the configuration file using filename inside config directory.
@@ -808,6 +993,24 @@ func (c Config) Save(filename string) (err error) {
 // .tm.hcl. It parses in non-strict mode for compatibility with older versions.
 // Note: it does not recurse into child directories.
 func ParseDir(root string, dir string) (Config, error) {
+	return ParseDirWithInheritedTags(root, dir, nil)
+}
+
+// ParseDirWithInheritedTags is like ParseDir but the directory inherits the
+// given tags from the stack_defaults blocks of its parent directories, then
+// they are available in terramate.stack.tags for the import conditions.
+func ParseDirWithInheritedTags(root string, dir string, inherited []InheritedTag) (Config, error) {
+	return parseDir(root, dir, inherited, false)
+}
+
+// ParseDirFetchingImports is like ParseDirWithInheritedTags but the remote
+// import sources which are not cached yet are fetched, instead of failing with
+// ErrImportNotFetched.
+func ParseDirFetchingImports(root string, dir string, inherited []InheritedTag) (Config, error) {
+	return parseDir(root, dir, inherited, true)
+}
+
+func parseDir(root string, dir string, inherited []InheritedTag, fetchImports bool) (Config, error) {
 	logger := log.With().
 		Str("action", "ParseDir()").
 		Str("dir", dir).
@@ -819,6 +1022,8 @@ func ParseDir(root string, dir string) (Config, error) {
 	if err != nil {
 		return Config{}, err
 	}
+	p.inheritedTags = inherited
+	p.fetchImports = fetchImports
 	err = p.AddDir(dir)
 	if err != nil {
 		return Config{}, errors.E("adding files to parser", err)
@@ -987,6 +1192,10 @@ func validateImportBlock(block *ast.Block) error {
 				Name:     "source",
 				Required: true,
 			},
+			{
+				Name:     "condition",
+				Required: false,
+			},
 		},
 	}
 
@@ -1425,7 +1634,7 @@ func parseRootConfig(cfg *RootConfig, block *ast.MergedBlock) error {
 		))
 	}
 
-	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "run", "cloud"))
+	errs.AppendWrap(ErrTerramateSchema, block.ValidateSubBlocks("git", "run", "cloud", "stacks"))
 
 	gitBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("git")]
 	if ok {
@@ -1462,6 +1671,17 @@ func parseRootConfig(cfg *RootConfig, block *ast.MergedBlock) error {
 		errs.Append(parseCloudConfig(cfg.Cloud, cloudBlock))
 	}
 
+	stacksBlock, ok := block.Blocks[ast.NewEmptyLabelBlockType("stacks")]
+	if ok {
+		logger.Trace().Msg("Type is 'stacks'")
+
+		cfg.Stacks = &StacksConfig{}
+
+		logger.Trace().Msg("Parse stacks config.")
+
+		errs.Append(parseStacksConfig(cfg.Stacks, stacksBlock))
+	}
+
 	return errs.AsError()
 }
 
@@ -1511,6 +1731,30 @@ func parseRunConfig(runCfg *RunConfig, runBlock *ast.MergedBlock) error {
 	return errs.AsError()
 }
 
+// parseDirRunConfig parses the top-level run block, which only supports the
+// env block.
+func parseDirRunConfig(runBlock *ast.MergedBlock) (*RunEnv, error) {
+	errs := errors.L()
+	for _, attr := range runBlock.Attributes.SortedList() {
+		errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+			"unrecognized attribute run.%s", attr.Name))
+	}
+
+	errs.AppendWrap(ErrTerramateSchema, runBlock.ValidateSubBlocks("env"))
+
+	var runEnv *RunEnv
+	block, ok := runBlock.Blocks[ast.NewEmptyLabelBlockType("env")]
+	if ok {
+		runEnv = &RunEnv{}
+		errs.Append(parseRunEnv(runEnv, block))
+	}
+
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return runEnv, nil
+}
+
 func parseRunEnv(runEnv *RunEnv, envBlock *ast.MergedBlock) error {
 	if len(envBlock.Attributes) > 0 {
 		runEnv.Attributes = envBlock.Attributes
@@ -1671,6 +1915,109 @@ func parseCloudConfig(cloud *CloudConfig, cloudBlock *ast.MergedBlock) error {
 	return errs.AsError()
 }
 
+func parseStacksConfig(stacks *StacksConfig, stacksBlock *ast.MergedBlock) error {
+	logger := log.With().
+		Str("action", "parseStacksConfig()").
+		Logger()
+
+	logger.Trace().Msg("Range over block attributes.")
+
+	errs := errors.L()
+
+	errs.AppendWrap(ErrTerramateSchema, stacksBlock.ValidateSubBlocks())
+
+	for _, attr := range stacksBlock.Attributes.SortedList() {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			errs.Append(errors.E(diags,
+				"failed to evaluate terramate.config.stacks.%s attribute", attr.Name,
+			))
+			continue
+		}
+
+		switch attr.Name {
+		case "allowed_tags":
+			if err := assignSet(attr.Attribute, &stacks.AllowedTags, value); err != nil {
+				errs.Append(err)
+				continue
+			}
+			errs.Append(validateTagsAttr(attr, stacks.AllowedTags))
+			stacks.AllowedTagsRange = attr.Range
+
+		case "required_tags":
+			if err := assignSet(attr.Attribute, &stacks.RequiredTags, value); err != nil {
+				errs.Append(err)
+				continue
+			}
+			errs.Append(validateTagsAttr(attr, stacks.RequiredTags))
+			stacks.RequiredTagsRange = attr.Range
+
+		case "name_pattern":
+			if value.Type() != cty.String || value.IsNull() {
+				errs.Append(attrErr(attr,
+					"terramate.config.stacks.name_pattern is not a string but %q",
+					value.Type().FriendlyName(),
+				))
+				continue
+			}
+			pattern, err := regexp.Compile(value.AsString())
+			if err != nil {
+				errs.Append(attrErr(attr,
+					"terramate.config.stacks.name_pattern is not a valid regular expression: %v",
+					err,
+				))
+				continue
+			}
+			stacks.NamePattern = pattern
+			stacks.NamePatternRange = attr.Range
+
+		case "require_id":
+			if value.Type() != cty.Bool || value.IsNull() {
+				errs.Append(attrErr(attr,
+					"terramate.config.stacks.require_id is not a bool but %q",
+					value.Type().FriendlyName(),
+				))
+				continue
+			}
+			stacks.RequireID = value.True()
+			stacks.RequireIDRange = attr.Range
+
+		default:
+			errs.Append(errors.E(
+				attr.NameRange,
+				"unrecognized attribute terramate.config.stacks.%s",
+				attr.Name,
+			))
+		}
+	}
+
+	if len(stacks.AllowedTags) > 0 {
+		allowed := map[string]bool{}
+		for _, tag := range stacks.AllowedTags {
+			allowed[tag] = true
+		}
+		for _, required := range stacks.RequiredTags {
+			if !allowed[required] {
+				errs.Append(errors.E(ErrTerramateSchema, stacks.RequiredTagsRange,
+					"terramate.config.stacks.required_tags has the tag %q which is not in allowed_tags",
+					required,
+				))
+			}
+		}
+	}
+	return errs.AsError()
+}
+
+func validateTagsAttr(attr ast.Attribute, tags []string) error {
+	errs := errors.L()
+	for _, tagname := range tags {
+		if err := tag.Validate(tagname); err != nil {
+			errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(), err))
+		}
+	}
+	return errs.AsError()
+}
+
 func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 	logger := log.With().
 		Str("action", "parseTerramateSchema()").
@@ -1731,6 +2078,54 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 			}
 			config.Asserts = append(config.Asserts, assertCfg)
 
+		case "global_schema":
+			logger.Trace().Msg("found global_schema block")
+			schema, err := parseGlobalSchemaBlock(block)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			for _, other := range config.GlobalSchemas {
+				if other.Name() == schema.Name() {
+					errs.Append(errors.E(errKind, block.DefRange(),
+						"global_schema %q already declared at %s",
+						schema.Name(), other.Range))
+				}
+			}
+			config.GlobalSchemas = append(config.GlobalSchemas, schema)
+
+		case GlobalsFileBlockType:
+			logger.Trace().Msg("found globals_file block")
+			globalsFile, err := p.parseGlobalsFileBlock(block)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			config.GlobalsFiles = append(config.GlobalsFiles, globalsFile)
+
+		case FunctionBlockType:
+			logger.Trace().Msg("found function block")
+			function, err := parseFunctionBlock(block)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			for _, other := range config.Functions {
+				if other.Name == function.Name {
+					errs.Append(errors.E(errKind, block.DefRange(),
+						"function %q already declared at %s",
+						function.Name, other.Range))
+				}
+			}
+			config.Functions = append(config.Functions, function)
+
+		case StackDefaultsBlockType:
+			logger.Trace().Msg("found stack_defaults block")
+			if config.StackDefaults == nil {
+				config.StackDefaults = &StackDefaultsConfig{}
+			}
+			errs.Append(p.parseStackDefaults(config.StackDefaults, block))
+
 		case "vendor":
 			logger.Trace().Msg("found vendor block")
 
@@ -1763,6 +2158,12 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 		}
 	}
 
+	runBlock, ok := rawconfig.MergedBlocks["run"]
+	if ok {
+		config.RunEnv, err = parseDirRunConfig(runBlock)
+		errs.Append(err)
+	}
+
 	tmBlock, ok := rawconfig.MergedBlocks["terramate"]
 	if ok {
 		var tmconfig Terramate
@@ -1817,6 +2218,8 @@ func (p *TerramateParser) parseTerramateSchema() (Config, error) {
 	}
 
 	config.Imported = p.Imported
+	config.ImportedFiles = p.ImportedFiles()
+	config.ImportedBy = p.importedBy
 
 	return config, nil
 }
@@ -0,0 +1,179 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+func TestHCLParserFunction(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []testcase{
+		{
+			name: "function without label fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function {
+						  result = 1
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function with invalid name fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "a.b" {
+						  result = 1
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function without result fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  params = [a]
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function with unknown attribute and block fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  result = 1
+						  description = "desc"
+						  body {
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function params not identifiers fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  params = ["a", b.c]
+						  result = 1
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function with duplicated param fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  params = [a, a]
+						  result = a
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "function result referencing globals fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  params = [a]
+						  result = "${a}-${global.suffix}"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "duplicated function in the same directory fails",
+			input: []cfgfile{
+				{
+					filename: "funcs.tm",
+					body: `
+						function "name" {
+						  result = 1
+						}
+					`,
+				},
+				{
+					filename: "other.tm",
+					body: `
+						function "name" {
+						  result = 2
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
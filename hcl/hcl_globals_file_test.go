@@ -0,0 +1,129 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"testing"
+
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+)
+
+func TestHCLParserGlobalsFile(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []testcase{
+		{
+			name: "globals_file without path fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file {
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "globals_file with unknown attribute fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file {
+						  path = "data.yaml"
+						  format = "yaml"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "globals_file with sub block fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file {
+						  path = "data.yaml"
+						  data {
+						  }
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "globals_file with invalid label fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file "a.b" {
+						  path = "data.yaml"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "globals_file.path not a string fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file {
+						  path = 1
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+		{
+			name: "globals_file.path outside project fails",
+			input: []cfgfile{
+				{
+					filename: "globals.tm",
+					body: `
+						globals_file {
+						  path = "../data.yaml"
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
+	} {
+		testParser(t, tc)
+	}
+}
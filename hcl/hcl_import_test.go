@@ -4,10 +4,16 @@
 package hcl_test
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/madlambda/spells/assert"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/test"
 	. "github.com/terramate-io/terramate/test/hclutils"
 )
 
@@ -368,7 +374,278 @@ func TestHCLImport(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "remote import without ref - fails",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body:     `import { source = "git::https://example.com/repo.git//a/*.tm.hcl" }`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrImport,
+						Mkrange("cfg.tm", Start(1, 19, 18), End(1, 66, 65))),
+				},
+			},
+		},
+		{
+			name: "remote import without files - fails",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body:     `import { source = "git::https://example.com/repo.git?ref=v1" }`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrImport,
+						Mkrange("cfg.tm", Start(1, 19, 18), End(1, 61, 60))),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
 }
+
+func TestHCLConditionalImportOnlyTerramateMetadata(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	test.WriteFile(t, rootdir, "imports/aws.tm.hcl", "globals {\n  cloud = \"aws\"\n}\n")
+	test.WriteFile(t, rootdir, "stack/stack.tm", "stack {}\n")
+	test.WriteFile(t, rootdir, "stack/import.tm", `import {
+  source    = "/imports/aws.tm.hcl"
+  condition = global.cloud == "aws"
+}
+`)
+
+	_, err := hcl.ParseDir(rootdir, filepath.Join(rootdir, "stack"))
+	assert.IsError(t, err, errors.E(hcl.ErrImport,
+		Mkrange(filepath.Join(rootdir, "stack/import.tm"), Start(3, 15, 59), End(3, 36, 80))))
+	if !strings.Contains(err.Error(), "only terramate metadata is available") {
+		t.Fatalf("error %q doesn't explain that only terramate metadata is available", err)
+	}
+}
+
+func TestHCLRemoteImport(t *testing.T) {
+	t.Parallel()
+
+	remoteRepo := test.NewRepo(t)
+	test.WriteFile(t, remoteRepo, "common/globals.tm.hcl", `globals {
+		team = "platform"
+	}`)
+	test.WriteFile(t, remoteRepo, "common/other.txt", "not imported")
+	g := test.NewGitWrapper(t, remoteRepo, []string{})
+	assert.NoError(t, g.Add(filepath.Join(remoteRepo, "common")))
+	assert.NoError(t, g.Commit("add common config"))
+
+	rootdir := test.TempDir(t)
+	stackdir := test.Mkdir(t, rootdir, "stack")
+	test.WriteFile(t, stackdir, "import.tm", fmt.Sprintf(`import {
+		source = "git::file://%s//common/*.tm.hcl?ref=main"
+	}`, filepath.ToSlash(remoteRepo)))
+
+	parse := func() hcl.Config {
+		t.Helper()
+		cfg, err := hcl.ParseDir(rootdir, stackdir)
+		assert.NoError(t, err)
+		return cfg
+	}
+
+	// parsing never fetches the remote imports.
+	_, err := hcl.ParseDir(rootdir, stackdir)
+	assert.IsError(t, err, errors.E(hcl.ErrImportNotFetched))
+
+	cfg, err := hcl.ParseDirFetchingImports(rootdir, stackdir, nil)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(cfg.ImportedFiles))
+
+	cfg = parse()
+	assert.EqualInts(t, 1, len(cfg.ImportedFiles))
+
+	cacheDir := filepath.Join(rootdir, filepath.FromSlash(hcl.RemoteImportsDir))
+	if !strings.HasPrefix(cfg.ImportedFiles[0], cacheDir) {
+		t.Fatalf("imported file %q is not inside the cache dir %q",
+			cfg.ImportedFiles[0], cacheDir)
+	}
+	if _, ok := cfg.Globals[ast.NewEmptyLabelBlockType("globals")]; !ok {
+		t.Fatalf("imported globals not found")
+	}
+
+	// the remote is never fetched again.
+	test.WriteFile(t, remoteRepo, "common/new.tm.hcl", `globals {
+		other = true
+	}`)
+	assert.NoError(t, g.Add(filepath.Join(remoteRepo, "common")))
+	assert.NoError(t, g.Commit("add new config"))
+
+	cfg, err = hcl.ParseDirFetchingImports(rootdir, stackdir, nil)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(cfg.ImportedFiles))
+}
+
+func TestHCLConditionalImport(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name      string
+		files     map[string]string
+		wantFiles []string
+		wantErr   error
+	}
+
+	const stackCfg = `stack {
+		name = "vpc"
+		tags = ["aws"]
+	}`
+
+	for _, tc := range []testcase{
+		{
+			name: "false condition is not imported",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source    = "/imports/gcp.tm.hcl"
+					condition = false
+				}`,
+			},
+		},
+		{
+			name: "condition using stack metadata",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = tm_contains(terramate.stack.tags, "aws")
+				}
+				import {
+					source    = "/imports/gcp.tm.hcl"
+					condition = tm_contains(terramate.stack.tags, "gcp")
+				}`,
+			},
+			wantFiles: []string{"imports/aws.tm.hcl"},
+		},
+		{
+			name: "source using stack metadata",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source = "/imports/${terramate.stack.tags[0]}.tm.hcl"
+				}`,
+			},
+			wantFiles: []string{"imports/aws.tm.hcl"},
+		},
+		{
+			name: "nested imports use the importing stack metadata",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source = "/nested/cloud.tm.hcl"
+				}`,
+				"nested/cloud.tm.hcl": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = terramate.stack.name == "vpc"
+				}`,
+			},
+			wantFiles: []string{"imports/aws.tm.hcl", "nested/cloud.tm.hcl"},
+		},
+		{
+			name: "condition referencing globals fails",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = global.cloud == "aws"
+				}`,
+			},
+			wantErr: errors.E(hcl.ErrImport),
+		},
+		{
+			name: "condition with non-boolean value fails",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = "true"
+				}`,
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name: "condition with null value fails",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = tobool(null)
+				}`,
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name: "source with null value fails",
+			files: map[string]string{
+				"stack/stack.tm": stackCfg,
+				"stack/import.tm": `import {
+					source = tostring(null)
+				}`,
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+		{
+			name: "condition using tags of stack_defaults in the stack directory",
+			files: map[string]string{
+				"stack/stack.tm": `stack {
+					name = "vpc"
+				}
+				stack_defaults {
+					tags = ["gcp"]
+				}`,
+				"stack/import.tm": `import {
+					source    = "/imports/gcp.tm.hcl"
+					condition = tm_contains(terramate.stack.tags, "gcp")
+				}`,
+			},
+			wantFiles: []string{"imports/gcp.tm.hcl"},
+		},
+		{
+			name: "stack metadata outside of stacks fails",
+			files: map[string]string{
+				"stack/import.tm": `import {
+					source    = "/imports/aws.tm.hcl"
+					condition = terramate.stack.name == "vpc"
+				}`,
+			},
+			wantErr: errors.E(hcl.ErrTerramateSchema),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rootdir := test.TempDir(t)
+			test.WriteFile(t, rootdir, "imports/aws.tm.hcl", "globals {\n  cloud = \"aws\"\n}\n")
+			test.WriteFile(t, rootdir, "imports/gcp.tm.hcl", "globals {\n  cloud = \"gcp\"\n}\n")
+			for name, body := range tc.files {
+				test.WriteFile(t, rootdir, name, body)
+			}
+
+			cfg, err := hcl.ParseDir(rootdir, filepath.Join(rootdir, "stack"))
+			assert.IsError(t, err, tc.wantErr)
+			if tc.wantErr != nil {
+				return
+			}
+
+			var want []string
+			for _, f := range tc.wantFiles {
+				want = append(want, filepath.Join(rootdir, filepath.FromSlash(f)))
+			}
+			assert.EqualInts(t, len(want), len(cfg.ImportedFiles))
+			for i, f := range want {
+				assert.EqualStrings(t, f, cfg.ImportedFiles[i])
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/test"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	. "github.com/terramate-io/terramate/test/hclutils"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestHCLParserJSONSyntax(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	test.WriteFile(t, rootdir, "common/globals.tm.json", `{
+	  "globals": {
+	    "obj": {"a": 1},
+	    "str": "${terramate.stack.name}"
+	  }
+	}`)
+	test.WriteFile(t, rootdir, "stack/stack.tm.json", `{
+	  "import": [
+	    {"source": "/common/globals.tm.json"}
+	  ],
+	  "stack": {
+	    "name": "json-stack",
+	    "tags": ["a", "b"]
+	  }
+	}`)
+
+	cfg, err := hcl.ParseDir(rootdir, filepath.Join(rootdir, "stack"))
+	assert.NoError(t, err)
+	assert.IsTrue(t, cfg.Stack != nil)
+	assert.EqualStrings(t, "json-stack", cfg.Stack.Name)
+	assert.EqualInts(t, 2, len(cfg.Stack.Tags))
+	assert.IsTrue(t, cfg.HasGlobals())
+	assert.EqualInts(t, 1, len(cfg.ImportedFiles))
+}
+
+func TestHCLParserJSONSyntaxStaticBlocks(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	test.WriteFile(t, rootdir, "config.tm.json", `{
+	  "global_schema": {"a": {"type": "list(string)"}},
+	  "globals_file": [
+	    {"path": "/data.json"},
+	    {"obj": {"sub": {"path": "/obj.json"}}}
+	  ],
+	  "function": {"add": {"params": ["x", "y"], "result": "${x + y}"}}
+	}`)
+
+	cfg, err := hcl.ParseDir(rootdir, rootdir)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(cfg.GlobalSchemas))
+	assert.IsTrue(t, cfg.GlobalSchemas[0].Type.Equals(cty.List(cty.String)))
+	assert.EqualInts(t, 2, len(cfg.GlobalsFiles))
+	assert.EqualInts(t, 0, len(cfg.GlobalsFiles[0].Labels))
+	assert.EqualStrings(t, "obj.sub", strings.Join(cfg.GlobalsFiles[1].Labels, "."))
+	assert.EqualInts(t, 1, len(cfg.Functions))
+	assert.EqualStrings(t, "x,y", strings.Join(cfg.Functions[0].Params, ","))
+}
+
+func TestHCLParserJSONSyntaxGenerateContent(t *testing.T) {
+	t.Parallel()
+
+	rootdir := test.TempDir(t)
+	test.WriteFile(t, rootdir, "gen.tm.json", `{
+	  "generate_hcl": {
+	    "main.tf": {
+	      "content": {
+	        "locals": [{"a": 1}],
+	        "resource": {
+	          "null_resource": {
+	            "a": [{"triggers": {"x": "y"}}],
+	            "b": [{}, {}]
+	          }
+	        },
+	        "tags": {"team": "infra"},
+	        "empty": []
+	      }
+	    }
+	  }
+	}`)
+
+	cfg, err := hcl.ParseDir(rootdir, rootdir)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 1, len(cfg.Generate.HCLs))
+
+	content := cfg.Generate.HCLs[0].Content.Body
+	assert.EqualInts(t, 2, len(content.Attributes))
+	assert.IsTrue(t, content.Attributes["tags"] != nil)
+	assert.IsTrue(t, content.Attributes["empty"] != nil)
+
+	var blocks []string
+	for _, block := range content.Blocks {
+		blocks = append(blocks, strings.Join(append([]string{block.Type}, block.Labels...), "."))
+	}
+	assert.EqualStrings(t,
+		"locals,resource.null_resource.a,resource.null_resource.b,resource.null_resource.b",
+		strings.Join(blocks, ","))
+	assert.IsTrue(t, content.Blocks[1].Body.Attributes["triggers"] != nil)
+}
+
+func TestHCLParserJSONSyntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		body string
+		want error
+	}{
+		{body: `{"stack": {}`, want: errors.E(hcl.ErrHCLSyntax)},
+		{body: `[]`, want: errors.E(hcl.ErrHCLSyntax)},
+		{body: `{"stack": {}} {}`, want: errors.E(hcl.ErrHCLSyntax)},
+		{body: `{"unknown": {}}`, want: errors.E(hcl.ErrTerramateSchema)},
+		{body: `{"stack": {"name": 1}}`, want: errors.E(hcl.ErrTerramateSchema)},
+		{
+			body: `{"globals": {"bad key": 1}}`,
+			want: errors.E(hcl.ErrHCLSyntax,
+				Mkrange("config.tm.json", Start(1, 14, 13), End(1, 23, 22))),
+		},
+		{
+			body: `{"globals": {"a": 1, "a": 2}}`,
+			want: errors.E(hcl.ErrHCLSyntax,
+				Mkrange("config.tm.json", Start(1, 22, 21), End(1, 25, 24))),
+		},
+		{
+			body: `{"stack": {"unknown": {}}}`,
+			want: errors.E(hcl.ErrTerramateSchema,
+				Mkrange("config.tm.json", Start(1, 12, 11), End(1, 21, 20))),
+		},
+		{
+			body: `{"globals": {"a": "${1 +}"}}`,
+			want: errors.E(hcl.ErrHCLSyntax,
+				Mkrange("config.tm.json", Start(1, 25, 24), End(1, 26, 25))),
+		},
+		{
+			body: `{"stack": {"name": 1}}`,
+			want: errors.E(hcl.ErrTerramateSchema,
+				Mkrange("config.tm.json", Start(1, 20, 19), End(1, 21, 20))),
+		},
+		{
+			body: `{"global_schema": {"a": {"type": "string x"}}}`,
+			want: errors.E(hcl.ErrHCLSyntax,
+				Mkrange("config.tm.json", Start(1, 34, 33), End(1, 44, 43))),
+		},
+	} {
+		rootdir := test.TempDir(t)
+		test.WriteFile(t, rootdir, "config.tm.json", tc.body)
+		_, err := hcl.ParseDir(rootdir, rootdir)
+		if e, ok := tc.want.(*errors.Error); ok && !e.FileRange.Empty() {
+			e.FileRange.Filename = filepath.Join(rootdir, e.FileRange.Filename)
+		}
+		errtest.Assert(t, err, tc.want)
+	}
+}
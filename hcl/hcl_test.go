@@ -6,6 +6,7 @@ package hcl_test
 import (
 	"fmt"
 	"path/filepath"
+	"regexp"
 	"testing"
 
 	"github.com/madlambda/spells/assert"
@@ -633,6 +634,70 @@ func TestHCLParserRootConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "basic config.stacks block",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								stacks {
+									allowed_tags  = ["prod", "dev", "team-a"]
+									required_tags = ["team-a"]
+									name_pattern  = "^[a-z-]+$"
+									require_id    = true
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				config: hcl.Config{
+					Terramate: &hcl.Terramate{
+						Config: &hcl.RootConfig{
+							Stacks: &hcl.StacksConfig{
+								AllowedTags:  []string{"prod", "dev", "team-a"},
+								RequiredTags: []string{"team-a"},
+								NamePattern:  regexp.MustCompile("^[a-z-]+$"),
+								RequireID:    true,
+							},
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "invalid config.stacks block",
+			input: []cfgfile{
+				{
+					filename: "cfg.tm",
+					body: `
+						terramate {
+							config {
+								stacks {
+									allowed_tags  = ["prod", "Dev"]
+									required_tags = ["team-a"]
+									name_pattern  = "["
+									require_id    = "yes"
+									unknown       = 1
+								}
+							}
+						}
+					`,
+				},
+			},
+			want: want{
+				errs: []error{
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+					errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 	} {
 		testParser(t, tc)
 	}
@@ -0,0 +1,112 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"path/filepath"
+
+	"github.com/terramate-io/terramate"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stdlib"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// importEvalContext returns the context used for evaluating the import
+// attributes. Only the terramate metadata known before the imports are applied
+// is available: the project root, the Terramate version and the stack
+// metadata if the directory is a stack. Globals are not available because
+// they can be defined by the imported files.
+func (p *TerramateParser) importEvalContext() *eval.Context {
+	if p.importRuntime == nil {
+		p.importRuntime = p.newImportRuntime()
+	}
+	evalctx := eval.NewContext(stdlib.Functions(p.dir))
+	evalctx.SetNamespace("terramate", p.importRuntime)
+	return evalctx
+}
+
+func (p *TerramateParser) newImportRuntime() project.Runtime {
+	rootfs := cty.ObjectVal(map[string]cty.Value{
+		"absolute": cty.StringVal(p.rootdir),
+		"basename": cty.StringVal(filepath.Base(p.rootdir)),
+	})
+	runtime := project.Runtime{
+		"root": cty.ObjectVal(map[string]cty.Value{
+			"path": cty.ObjectVal(map[string]cty.Value{
+				"fs": rootfs,
+			}),
+		}),
+		"version": cty.StringVal(terramate.Version()),
+	}
+
+	// stack blocks cannot be imported, so the stack metadata can be computed
+	// from the files in the directory.
+	bodies := p.ParsedBodies()
+	var stack *Stack
+	defaults := &StackDefaultsConfig{}
+	for _, filename := range p.sortedParsedFilenames() {
+		for _, rawBlock := range bodies[filename].Blocks {
+			switch rawBlock.Type {
+			case StackBlockType:
+				var err error
+				stack, err = p.parseStack(ast.NewBlock(p.rootdir, rawBlock))
+				if err != nil {
+					// reported when parsing the stack block.
+					return runtime
+				}
+			case StackDefaultsBlockType:
+				// errors are reported when parsing the stack_defaults block.
+				_ = p.parseStackDefaults(defaults, ast.NewBlock(p.rootdir, rawBlock))
+			}
+		}
+	}
+	if stack == nil {
+		return runtime
+	}
+	// stack_defaults blocks of the directory itself, not imported ones, are
+	// also inherited by the stack.
+	stack.InheritedTags = append(p.inheritedTags[:len(p.inheritedTags):len(p.inheritedTags)], defaults.Tags...)
+	runtime.Merge(p.stackImportRuntime(stack))
+	return runtime
+}
+
+func (p *TerramateParser) stackImportRuntime(stack *Stack) project.Runtime {
+	name := stack.Name
+	if name == "" {
+		name = filepath.Base(p.dir)
+	}
+	toRoot, _ := filepath.Rel(p.dir, p.rootdir)
+	return project.StackRuntime(project.StackMetadata{
+		Dir:         project.PrjAbsPath(p.rootdir, p.dir),
+		Name:        name,
+		Description: stack.Description,
+		ID:          stack.ID,
+		Tags:        stack.EffectiveTags(),
+		ToRoot:      toRoot,
+	}, nil)
+}
+
+// evalImportAttr evaluates the import attribute, failing if it references
+// anything other than the terramate metadata.
+func (p *TerramateParser) evalImportAttr(attr ast.Attribute) (cty.Value, error) {
+	for _, traversal := range attr.Expr.Variables() {
+		if ns := traversal.RootName(); ns != "terramate" {
+			return cty.NilVal, errors.E(ErrImport, attr.Expr.Range(),
+				"import.%s cannot reference %q: only terramate metadata is "+
+					"available because imports are applied before evaluating "+
+					"globals, which may be defined by the imported files",
+				attr.Name, ns)
+		}
+	}
+
+	val, err := p.importEvalContext().Eval(attr.Expr)
+	if err != nil {
+		return cty.NilVal, errors.E(ErrTerramateSchema, attr.Expr.Range(), err,
+			"failed to evaluate import.%s", attr.Name)
+	}
+	return val, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/modvendor"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/tf"
+)
+
+// RemoteImportsDir is the project directory where remote import sources are
+// cached. Each source is stored at <RemoteImportsDir>/<Source.Path>/<Source.Ref>.
+const RemoteImportsDir = "/.terramate-cache/imports"
+
+// IsRemoteImport tells if the import source is a remote git source.
+func IsRemoteImport(src string) bool {
+	for _, prefix := range []string{"git::", "git@", "github.com/"} {
+		if strings.HasPrefix(src, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoteImportDir returns the host directory where the remote import source is
+// cached. It fails with ErrImportNotFetched if the source was not fetched yet,
+// as parsing the configuration never accesses the network.
+func RemoteImportDir(rootdir string, modsrc tf.Source) (string, error) {
+	cacheDir, err := remoteImportCacheDir(rootdir, modsrc)
+	if err != nil {
+		return "", err
+	}
+	if st, err := os.Stat(cacheDir); err != nil || !st.IsDir() {
+		return "", errors.E(ErrImportNotFetched,
+			"source %q must be fetched with `terramate experimental vendor imports`",
+			modsrc.Raw)
+	}
+	return cacheDir, nil
+}
+
+// FetchRemoteImport is like RemoteImportDir but it fetches the source if it's
+// not cached yet. The sources are fetched only once, so the ref is expected to
+// be immutable (eg.: a tag or commit). Removing the cached directory forces
+// the source to be fetched again.
+func FetchRemoteImport(rootdir string, modsrc tf.Source) (string, error) {
+	logger := log.With().
+		Str("action", "hcl.FetchRemoteImport()").
+		Str("source", modsrc.Raw).
+		Logger()
+
+	cacheDir, err := remoteImportCacheDir(rootdir, modsrc)
+	if err != nil {
+		return "", err
+	}
+	if st, err := os.Stat(cacheDir); err == nil && st.IsDir() {
+		logger.Trace().Str("dir", cacheDir).Msg("using cached remote import")
+		return cacheDir, nil
+	}
+
+	logger.Info().Msg("fetching remote import")
+
+	// clone outside the project, see modvendor.Clone.
+	clonedDir, err := os.MkdirTemp("", ".tmimport")
+	if err != nil {
+		return "", errors.E(ErrImport, err, "creating tmp clone dir")
+	}
+	defer func() {
+		if err := os.RemoveAll(clonedDir); err != nil {
+			logger.Warn().Err(err).Msg("deleting tmp clone dir")
+		}
+	}()
+
+	if err := modvendor.Clone(modsrc, clonedDir); err != nil {
+		return "", errors.E(ErrImport, err, "fetching remote import %q", modsrc.Raw)
+	}
+
+	cacheRoot := filepath.Join(rootdir, filepath.FromSlash(RemoteImportsDir))
+	if err := os.MkdirAll(cacheRoot, 0775); err != nil {
+		return "", errors.E(ErrImport, err, "creating remote imports dir")
+	}
+
+	// the cache must never show up as untracked files in the project.
+	ignoreFile := filepath.Join(filepath.Dir(cacheRoot), ".gitignore")
+	if _, err := os.Stat(ignoreFile); err != nil {
+		if err := os.WriteFile(ignoreFile, []byte("*\n"), 0644); err != nil {
+			return "", errors.E(ErrImport, err, "creating remote imports .gitignore")
+		}
+	}
+
+	// copy into a temp dir in the same filesystem, so the final rename is
+	// atomic and partially copied sources are never used.
+	tmpDir, err := os.MkdirTemp(cacheRoot, ".tmp")
+	if err != nil {
+		return "", errors.E(ErrImport, err, "creating tmp dir inside project")
+	}
+	defer func() {
+		if err := os.RemoveAll(tmpDir); err != nil {
+			logger.Warn().Err(err).Msg("deleting tmp dir inside project")
+		}
+	}()
+
+	copyAll := func(string, os.DirEntry) bool { return true }
+	if err := fs.CopyDir(tmpDir, clonedDir, copyAll); err != nil {
+		return "", errors.E(ErrImport, err, "copying remote import")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cacheDir), 0775); err != nil {
+		return "", errors.E(ErrImport, err, "creating remote import dir")
+	}
+
+	if err := os.Rename(tmpDir, cacheDir); err != nil {
+		if st, statErr := os.Stat(cacheDir); statErr == nil && st.IsDir() {
+			// fetched concurrently by another parser.
+			return cacheDir, nil
+		}
+		return "", errors.E(ErrImport, err, "moving remote import to %q", cacheDir)
+	}
+	return cacheDir, nil
+}
+
+func remoteImportCacheDir(rootdir string, modsrc tf.Source) (string, error) {
+	if modsrc.Ref == "" {
+		return "", errors.E(ErrImport,
+			"remote import source %q must be pinned with a ?ref=", modsrc.Raw)
+	}
+	return modvendor.AbsVendorDir(rootdir, project.NewPath(RemoteImportsDir), modsrc), nil
+}
@@ -0,0 +1,567 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// The JSON syntax of the Terramate configuration follows the HCL JSON
+// specification: https://github.com/hashicorp/hcl/blob/main/json/spec.md
+//
+// The specification relies on a schema to tell blocks from attributes, but
+// the Terramate parser works on the native syntax tree, then the JSON files
+// are decoded into the native syntax tree using the schema of the top-level
+// blocks. This way the JSON files have exactly the same semantics as the
+// native ones, and the nodes of the tree have the ranges of the JSON file.
+
+// blockSchema is the structure of a block. The native syntax tells blocks
+// from attributes, then it's only needed by the JSON syntax.
+type blockSchema struct {
+	// labels is the number of labels of the block.
+	labels int
+
+	// bodyAttr, if set, makes the labels optional: object keys are taken as
+	// labels, up to maxLabels, until reaching an object with this attribute.
+	bodyAttr  string
+	maxLabels int
+
+	// attrs tells if the body has arbitrary attributes, then object values
+	// are attributes, except for the blocks below. Otherwise the object values
+	// are blocks and the unknown ones are rejected by the parser.
+	attrs  bool
+	blocks map[string]blockSchema
+
+	// anyBlocks tells if the body has no schema, like the generate_hcl
+	// content. Then lists of objects are blocks, the keys of the objects
+	// enclosing them are their labels, and any other value is an attribute.
+	anyBlocks bool
+
+	// listBodies tells if the block bodies are always inside lists, then the
+	// keys of the enclosing objects are labels. It's the case of the blocks
+	// inside bodies without schema.
+	listBodies bool
+
+	// exprAttrs are the attributes whose values are statically analyzed, then
+	// their JSON strings (or lists of strings) are native expressions instead
+	// of templates, as defined by the HCL JSON specification.
+	exprAttrs map[string]bool
+}
+
+type (
+	// jsonValue is a decoded JSON value: nil, bool, json.Number, string,
+	// jsonObject or []jsonValue, with its byte offsets in the JSON file.
+	jsonValue struct {
+		val        any
+		start, end int
+	}
+
+	jsonObject []jsonField
+	jsonField  struct {
+		key              string
+		keyStart, keyEnd int
+		value            jsonValue
+	}
+)
+
+// parseJSONFile parses the Terramate configuration file in the JSON syntax.
+func (p *TerramateParser) parseJSONFile(filename string, data []byte) error {
+	body, err := parseJSONBody(filename, data)
+	if err != nil {
+		return err
+	}
+	p.hclparser.AddFile(filename, &hhcl.File{
+		Body:  body,
+		Bytes: data,
+	})
+	return nil
+}
+
+// parseJSONBody decodes the Terramate configuration in the JSON syntax into
+// the equivalent native syntax tree.
+func parseJSONBody(filename string, data []byte) (*hclsyntax.Body, error) {
+	file := newJSONFile(filename, data)
+	dec := &jsonDecoder{
+		data: data,
+		dec:  json.NewDecoder(bytes.NewReader(data)),
+	}
+	dec.dec.UseNumber()
+
+	val, err := dec.decodeValue()
+	if err == nil {
+		if _, _, _, tokErr := dec.token(); tokErr != io.EOF {
+			err = errors.E("unexpected data after the top-level object")
+		}
+	}
+	if err != nil {
+		return nil, errors.E(ErrHCLSyntax, err, "parsing JSON file %s", filename)
+	}
+
+	obj, ok := val.val.(jsonObject)
+	if !ok {
+		return nil, errors.E(ErrHCLSyntax, file.jsonRange(val.start, val.end),
+			"the root value of the JSON file must be an object")
+	}
+
+	return file.body(obj, val, fileSchema())
+}
+
+// fileSchema returns the schema of a configuration file, whose blocks are
+// the top-level blocks handled by the parser.
+func fileSchema() blockSchema {
+	schema := blockSchema{
+		blocks: make(map[string]blockSchema, len(topLevelBlocks)),
+	}
+	for name, block := range topLevelBlocks {
+		schema.blocks[name] = block.schema
+	}
+	return schema
+}
+
+type jsonDecoder struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// token returns the next token with its start and end offsets.
+func (d *jsonDecoder) token() (json.Token, int, int, error) {
+	start := int(d.dec.InputOffset())
+	for start < len(d.data) && strings.IndexByte(" \t\r\n:,", d.data[start]) >= 0 {
+		start++
+	}
+	tok, err := d.dec.Token()
+	return tok, start, int(d.dec.InputOffset()), err
+}
+
+func (d *jsonDecoder) decodeValue() (jsonValue, error) {
+	tok, start, end, err := d.token()
+	if err != nil {
+		return jsonValue{}, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return jsonValue{val: tok, start: start, end: end}, nil
+	}
+	switch delim {
+	case '{':
+		obj := jsonObject{}
+		for d.dec.More() {
+			keytok, keyStart, keyEnd, err := d.token()
+			if err != nil {
+				return jsonValue{}, err
+			}
+			val, err := d.decodeValue()
+			if err != nil {
+				return jsonValue{}, err
+			}
+			obj = append(obj, jsonField{
+				key:      keytok.(string),
+				keyStart: keyStart,
+				keyEnd:   keyEnd,
+				value:    val,
+			})
+		}
+		_, _, end, err := d.token()
+		return jsonValue{val: obj, start: start, end: end}, err
+	case '[':
+		list := []jsonValue{}
+		for d.dec.More() {
+			val, err := d.decodeValue()
+			if err != nil {
+				return jsonValue{}, err
+			}
+			list = append(list, val)
+		}
+		_, _, end, err := d.token()
+		return jsonValue{val: list, start: start, end: end}, err
+	}
+	return jsonValue{}, errors.E("unexpected delimiter %s", delim.String())
+}
+
+// jsonFile builds the native syntax tree of a JSON file.
+type jsonFile struct {
+	filename   string
+	data       []byte
+	lineStarts []int
+}
+
+func newJSONFile(filename string, data []byte) *jsonFile {
+	lineStarts := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineStarts = append(lineStarts, i+1)
+		}
+	}
+	return &jsonFile{
+		filename:   filename,
+		data:       data,
+		lineStarts: lineStarts,
+	}
+}
+
+func (f *jsonFile) body(obj jsonObject, val jsonValue, schema blockSchema) (*hclsyntax.Body, error) {
+	body := &hclsyntax.Body{
+		Attributes: hclsyntax.Attributes{},
+		SrcRange:   f.jsonRange(val.start, val.end),
+		EndRange:   f.jsonRange(val.end, val.end),
+	}
+
+	errs := errors.L()
+	for _, field := range obj {
+		keyRange := f.jsonRange(field.keyStart, field.keyEnd)
+		if !hclsyntax.ValidIdentifier(field.key) {
+			errs.Append(errors.E(ErrHCLSyntax, keyRange,
+				"%q is not a valid attribute or block name", field.key))
+			continue
+		}
+
+		if blockSchema, isBlock := blockSchemaOf(field, schema); isBlock {
+			blocks, err := f.blocks(field.key, nil, nil, keyRange, field.value, blockSchema)
+			if err != nil {
+				errs.Append(err)
+				continue
+			}
+			body.Blocks = append(body.Blocks, blocks...)
+			continue
+		}
+
+		if _, ok := body.Attributes[field.key]; ok {
+			errs.Append(errors.E(ErrHCLSyntax, keyRange,
+				"attribute %q redefined", field.key))
+			continue
+		}
+
+		var (
+			expr hclsyntax.Expression
+			err  error
+		)
+		if schema.exprAttrs[field.key] {
+			expr, err = f.staticExpr(field.value)
+		} else {
+			expr, err = f.expr(field.value)
+		}
+		if err != nil {
+			errs.Append(err)
+			continue
+		}
+		body.Attributes[field.key] = &hclsyntax.Attribute{
+			Name:        field.key,
+			Expr:        expr,
+			SrcRange:    f.jsonRange(field.keyStart, field.value.end),
+			NameRange:   keyRange,
+			EqualsRange: f.jsonRange(field.keyEnd, field.value.start),
+		}
+	}
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// blockSchemaOf returns the schema of the field block, or false if the field
+// is an attribute.
+func blockSchemaOf(field jsonField, parent blockSchema) (blockSchema, bool) {
+	if parent.anyBlocks {
+		schema := blockSchema{
+			anyBlocks:  true,
+			listBodies: true,
+		}
+		return schema, isJSONBlocksDecl(field.value)
+	}
+	if !isJSONBlockValue(field.value) {
+		return blockSchema{}, false
+	}
+	if schema, ok := parent.blocks[field.key]; ok {
+		return schema, true
+	}
+	return blockSchema{}, !parent.attrs
+}
+
+// isJSONBlockValue tells if the value can represent blocks: an object or a
+// non-empty list of objects.
+func isJSONBlockValue(val jsonValue) bool {
+	switch val := val.val.(type) {
+	case jsonObject:
+		return true
+	case []jsonValue:
+		return isJSONObjectList(val)
+	}
+	return false
+}
+
+// isJSONBlocksDecl tells if the value declares blocks in a body without
+// schema: a non-empty list of objects, which are the bodies of the blocks, or
+// a non-empty object whose keys are block labels and whose values also
+// declare blocks.
+func isJSONBlocksDecl(val jsonValue) bool {
+	switch val := val.val.(type) {
+	case jsonObject:
+		if len(val) == 0 {
+			return false
+		}
+		for _, field := range val {
+			if !isJSONBlocksDecl(field.value) {
+				return false
+			}
+		}
+		return true
+	case []jsonValue:
+		return isJSONObjectList(val)
+	}
+	return false
+}
+
+func isJSONObjectList(list []jsonValue) bool {
+	if len(list) == 0 {
+		return false
+	}
+	for _, elem := range list {
+		if _, ok := elem.val.(jsonObject); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *jsonFile) blocks(
+	blockType string,
+	labels []string,
+	labelRanges []hhcl.Range,
+	typeRange hhcl.Range,
+	val jsonValue,
+	schema blockSchema,
+) ([]*hclsyntax.Block, error) {
+	if list, ok := val.val.([]jsonValue); ok {
+		var blocks []*hclsyntax.Block
+		errs := errors.L()
+		for _, elem := range list {
+			if schema.listBodies {
+				block, err := f.block(blockType, labels, labelRanges, typeRange, elem, schema)
+				errs.Append(err)
+				blocks = append(blocks, block)
+				continue
+			}
+			elemBlocks, err := f.blocks(blockType, labels, labelRanges, typeRange, elem, schema)
+			errs.Append(err)
+			blocks = append(blocks, elemBlocks...)
+		}
+		if err := errs.AsError(); err != nil {
+			return nil, err
+		}
+		return blocks, nil
+	}
+
+	obj, ok := val.val.(jsonObject)
+	if !ok {
+		return nil, errors.E(ErrHCLSyntax, f.jsonRange(val.start, val.end),
+			"block %s %s must be an object or a list of objects",
+			blockType, strings.Join(labels, " "))
+	}
+
+	if !hasMoreLabels(obj, labels, schema) {
+		block, err := f.block(blockType, labels, labelRanges, typeRange, val, schema)
+		if err != nil {
+			return nil, err
+		}
+		return []*hclsyntax.Block{block}, nil
+	}
+
+	var blocks []*hclsyntax.Block
+	errs := errors.L()
+	for _, field := range obj {
+		sublabels := append(append([]string{}, labels...), field.key)
+		subranges := append(append([]hhcl.Range{}, labelRanges...),
+			f.jsonRange(field.keyStart, field.keyEnd))
+		labelBlocks, err := f.blocks(blockType, sublabels, subranges, typeRange, field.value, schema)
+		errs.Append(err)
+		blocks = append(blocks, labelBlocks...)
+	}
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func (f *jsonFile) block(
+	blockType string,
+	labels []string,
+	labelRanges []hhcl.Range,
+	typeRange hhcl.Range,
+	val jsonValue,
+	schema blockSchema,
+) (*hclsyntax.Block, error) {
+	body, err := f.body(val.val.(jsonObject), val, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &hclsyntax.Block{
+		Type:            blockType,
+		Labels:          labels,
+		Body:            body,
+		TypeRange:       typeRange,
+		LabelRanges:     labelRanges,
+		OpenBraceRange:  f.jsonRange(val.start, val.start+1),
+		CloseBraceRange: f.jsonRange(val.end-1, val.end),
+	}, nil
+}
+
+// hasMoreLabels tells if the keys of the object are labels of the block.
+func hasMoreLabels(obj jsonObject, labels []string, schema blockSchema) bool {
+	if schema.listBodies {
+		return true
+	}
+	if schema.bodyAttr == "" {
+		return len(labels) < schema.labels
+	}
+	if len(labels) >= schema.maxLabels {
+		return false
+	}
+	for _, field := range obj {
+		if field.key == schema.bodyAttr {
+			return false
+		}
+	}
+	return true
+}
+
+// expr returns the expression of the JSON value. The strings are templates,
+// as defined by the HCL JSON specification.
+func (f *jsonFile) expr(val jsonValue) (hclsyntax.Expression, error) {
+	srcRange := f.jsonRange(val.start, val.end)
+	switch v := val.val.(type) {
+	case nil:
+		return &hclsyntax.LiteralValueExpr{
+			Val:      cty.NullVal(cty.DynamicPseudoType),
+			SrcRange: srcRange,
+		}, nil
+	case bool:
+		return &hclsyntax.LiteralValueExpr{
+			Val:      cty.BoolVal(v),
+			SrcRange: srcRange,
+		}, nil
+	case json.Number:
+		num, err := cty.ParseNumberVal(v.String())
+		if err != nil {
+			return nil, errors.E(ErrHCLSyntax, srcRange, err, "invalid number")
+		}
+		return &hclsyntax.LiteralValueExpr{
+			Val:      num,
+			SrcRange: srcRange,
+		}, nil
+	case string:
+		return f.template(v, val.start)
+	case []jsonValue:
+		tuple := &hclsyntax.TupleConsExpr{
+			SrcRange:  srcRange,
+			OpenRange: f.jsonRange(val.start, val.start+1),
+		}
+		errs := errors.L()
+		for _, elem := range v {
+			expr, err := f.expr(elem)
+			errs.Append(err)
+			tuple.Exprs = append(tuple.Exprs, expr)
+		}
+		if err := errs.AsError(); err != nil {
+			return nil, err
+		}
+		return tuple, nil
+	case jsonObject:
+		obj := &hclsyntax.ObjectConsExpr{
+			SrcRange:  srcRange,
+			OpenRange: f.jsonRange(val.start, val.start+1),
+		}
+		errs := errors.L()
+		for _, field := range v {
+			key, err := f.template(field.key, field.keyStart)
+			errs.Append(err)
+			value, err := f.expr(field.value)
+			errs.Append(err)
+			obj.Items = append(obj.Items, hclsyntax.ObjectConsItem{
+				KeyExpr:   &hclsyntax.ObjectConsKeyExpr{Wrapped: key},
+				ValueExpr: value,
+			})
+		}
+		if err := errs.AsError(); err != nil {
+			return nil, err
+		}
+		return obj, nil
+	}
+	panic(errors.E(errors.ErrInternal, "unexpected JSON value %T", val.val))
+}
+
+// staticExpr returns the expression of the JSON string, or of each string
+// of a list of strings, as defined by the HCL JSON specification for the
+// attributes which are statically analyzed, like type constraints.
+func (f *jsonFile) staticExpr(val jsonValue) (hclsyntax.Expression, error) {
+	switch v := val.val.(type) {
+	case string:
+		expr, diags := hclsyntax.ParseExpression([]byte(v), f.filename, f.jsonPos(val.start+1))
+		if diags.HasErrors() {
+			return nil, errors.E(ErrHCLSyntax, f.jsonRange(val.start, val.end),
+				"%q is not a valid expression", v)
+		}
+		return expr, nil
+	case []jsonValue:
+		tuple := &hclsyntax.TupleConsExpr{
+			SrcRange:  f.jsonRange(val.start, val.end),
+			OpenRange: f.jsonRange(val.start, val.start+1),
+		}
+		errs := errors.L()
+		for _, elem := range v {
+			expr, err := f.staticExpr(elem)
+			errs.Append(err)
+			tuple.Exprs = append(tuple.Exprs, expr)
+		}
+		if err := errs.AsError(); err != nil {
+			return nil, err
+		}
+		return tuple, nil
+	}
+	return f.expr(val)
+}
+
+// template parses the JSON string starting at the start offset as a template.
+// The positions inside the template are exact unless the JSON string has
+// escape sequences.
+func (f *jsonFile) template(s string, start int) (hclsyntax.Expression, error) {
+	expr, diags := hclsyntax.ParseTemplate([]byte(s), f.filename, f.jsonPos(start+1))
+	if diags.HasErrors() {
+		return nil, errors.E(ErrHCLSyntax, diags)
+	}
+	return expr, nil
+}
+
+// jsonPos returns the position of the JSON file byte offset.
+func (f *jsonFile) jsonPos(offset int) hhcl.Pos {
+	if offset > len(f.data) {
+		offset = len(f.data)
+	}
+	line := sort.Search(len(f.lineStarts), func(i int) bool {
+		return f.lineStarts[i] > offset
+	}) - 1
+	lineStart := f.lineStarts[line]
+	return hhcl.Pos{
+		Line:   line + 1,
+		Column: utf8.RuneCount(f.data[lineStart:offset]) + 1,
+		Byte:   offset,
+	}
+}
+
+func (f *jsonFile) jsonRange(start, end int) hhcl.Range {
+	return hhcl.Range{
+		Filename: f.filename,
+		Start:    f.jsonPos(start),
+		End:      f.jsonPos(end),
+	}
+}
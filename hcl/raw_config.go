@@ -8,6 +8,7 @@ import (
 
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/project"
 )
 
 // RawConfig is the configuration (attributes and blocks) without schema
@@ -34,19 +35,117 @@ type RawConfig struct {
 
 type mergeHandler func(r *RawConfig, block *ast.Block) error
 
+// topLevelBlock is a top-level block of the Terramate configuration.
+type topLevelBlock struct {
+	// merge merges the block into the RawConfig.
+	merge mergeHandler
+
+	// schema is the structure of the block, needed by the JSON syntax.
+	schema blockSchema
+}
+
+var (
+	letsSchema = blockSchema{
+		attrs: true,
+		blocks: map[string]blockSchema{
+			"map": {labels: 1},
+		},
+	}
+
+	// topLevelBlocks are all the blocks handled by NewTopLevelRawConfig.
+	topLevelBlocks = map[string]topLevelBlock{
+		"terramate": {merge: (*RawConfig).mergeBlock},
+		"run": {
+			merge: (*RawConfig).mergeBlock,
+			schema: blockSchema{
+				blocks: map[string]blockSchema{
+					"env": {attrs: true},
+				},
+			},
+		},
+		"globals": {
+			merge: (*RawConfig).mergeLabeledBlock,
+			schema: blockSchema{
+				attrs: true,
+				blocks: map[string]blockSchema{
+					"map": {labels: 1},
+				},
+			},
+		},
+		"stack": {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				blocks: map[string]blockSchema{
+					StackMatrixBlockType: {attrs: true},
+				},
+			},
+		},
+		"vendor": {merge: (*RawConfig).addBlock},
+		"generate_file": {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				labels: 1,
+				blocks: map[string]blockSchema{
+					"lets": letsSchema,
+				},
+			},
+		},
+		"generate_hcl": {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				labels: 1,
+				blocks: map[string]blockSchema{
+					"lets":    letsSchema,
+					"content": {anyBlocks: true},
+				},
+			},
+		},
+		"assert": {
+			merge:  (*RawConfig).addBlock,
+			schema: blockSchema{attrs: true},
+		},
+		"global_schema": {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				labels:    1,
+				exprAttrs: map[string]bool{"type": true},
+			},
+		},
+		StackDefaultsBlockType: {
+			merge:  (*RawConfig).addBlock,
+			schema: blockSchema{attrs: true},
+		},
+		GlobalsFileBlockType: {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				bodyAttr:  "path",
+				maxLabels: project.MaxGlobalLabels,
+				attrs:     true,
+			},
+		},
+		FunctionBlockType: {
+			merge: (*RawConfig).addBlock,
+			schema: blockSchema{
+				labels:    1,
+				attrs:     true,
+				exprAttrs: map[string]bool{"params": true},
+			},
+		},
+		"import": {
+			merge:  func(r *RawConfig, b *ast.Block) error { return nil },
+			schema: blockSchema{attrs: true},
+		},
+	}
+)
+
 // NewTopLevelRawConfig returns a new RawConfig object tailored for the
 // Terramate top-level attributes and blocks.
 func NewTopLevelRawConfig() RawConfig {
-	return NewCustomRawConfig(map[string]mergeHandler{
-		"terramate":     (*RawConfig).mergeBlock,
-		"globals":       (*RawConfig).mergeLabeledBlock,
-		"stack":         (*RawConfig).addBlock,
-		"vendor":        (*RawConfig).addBlock,
-		"generate_file": (*RawConfig).addBlock,
-		"generate_hcl":  (*RawConfig).addBlock,
-		"assert":        (*RawConfig).addBlock,
-		"import":        func(r *RawConfig, b *ast.Block) error { return nil },
-	})
+	handlers := make(map[string]mergeHandler, len(topLevelBlocks))
+	for name, block := range topLevelBlocks {
+		handlers[name] = block.merge
+	}
+	return NewCustomRawConfig(handlers)
 }
 
 // NewCustomRawConfig returns a new customized RawConfig.
@@ -0,0 +1,111 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"github.com/terramate-io/terramate/config/tag"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+	"github.com/terramate-io/terramate/hcl/info"
+)
+
+// StackDefaultsBlockType is the name of the block declaring the defaults of
+// the stacks of a directory and its subdirectories.
+const StackDefaultsBlockType = "stack_defaults"
+
+// StackDefaultsConfig represents the stack_defaults blocks of a directory.
+type StackDefaultsConfig struct {
+	// Tags are the tags inherited by all the stacks in the directory and in
+	// its subdirectories.
+	Tags []InheritedTag
+}
+
+// InheritedTag is a tag declared in a stack_defaults block.
+type InheritedTag struct {
+	// Name of the tag.
+	Name string
+
+	// Origin is the range of the stack_defaults.tags attribute declaring the
+	// tag.
+	Origin info.Range
+}
+
+func (p *TerramateParser) parseStackDefaults(cfg *StackDefaultsConfig, block *ast.Block) error {
+	errs := errors.L()
+	if len(block.Labels) > 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.DefRange(),
+			"stack_defaults must have no labels"))
+	}
+	for _, subBlock := range block.Blocks {
+		errs.Append(errors.E(ErrTerramateSchema, subBlock.DefRange(),
+			"unrecognized block stack_defaults.%s", subBlock.Type))
+	}
+
+	for _, attr := range block.Attributes.SortedList() {
+		if attr.Name != "tags" {
+			errs.Append(errors.E(ErrTerramateSchema, attr.NameRange,
+				"unrecognized attribute stack_defaults.%s", attr.Name))
+			continue
+		}
+
+		val, err := p.evalctx.Eval(attr.Expr)
+		if err != nil {
+			errs.Append(errors.E(ErrTerramateSchema, err,
+				"failed to evaluate stack_defaults.tags attribute"))
+			continue
+		}
+
+		var tags []string
+		if err := assignSet(attr.Attribute, &tags, val); err != nil {
+			errs.Append(err)
+			continue
+		}
+
+		for _, tagname := range tags {
+			if err := tag.Validate(tagname); err != nil {
+				errs.Append(errors.E(ErrTerramateSchema, attr.Expr.Range(), err))
+				continue
+			}
+			if cfg.hasTag(tagname) {
+				continue
+			}
+			cfg.Tags = append(cfg.Tags, InheritedTag{
+				Name:   tagname,
+				Origin: attr.Range,
+			})
+		}
+	}
+	return errs.AsError()
+}
+
+func (cfg *StackDefaultsConfig) hasTag(name string) bool {
+	for _, tag := range cfg.Tags {
+		if tag.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// EffectiveTags returns the tags of the stack followed by the tags inherited
+// from the stack_defaults of the parent directories.
+func (s *Stack) EffectiveTags() []string {
+	if len(s.InheritedTags) == 0 {
+		return s.Tags
+	}
+	seen := map[string]struct{}{}
+	tags := make([]string, 0, len(s.Tags)+len(s.InheritedTags))
+	for _, tag := range s.Tags {
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+	for _, inherited := range s.InheritedTags {
+		if _, ok := seen[inherited.Name]; ok {
+			continue
+		}
+		seen[inherited.Name] = struct{}{}
+		tags = append(tags, inherited.Name)
+	}
+	return tags
+}
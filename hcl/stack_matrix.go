@@ -0,0 +1,193 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package hcl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/hcl/ast"
+)
+
+// StackMatrixBlockType is the name of the block declaring a stack matrix.
+const StackMatrixBlockType = "matrix"
+
+// matrixValueRegex restricts the matrix values to the charset of the stack
+// IDs, as the values are part of the combination directories and IDs.
+var matrixValueRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_-]*$`)
+
+// maxMatrixIDLength is the maximum length of the stack IDs.
+const maxMatrixIDLength = 64
+
+// matrixIDHashLength is the length of the hash suffix of the combination IDs
+// exceeding the maximum stack ID length.
+const matrixIDHashLength = 16
+
+// IsMatrix tells if the stack is a matrix definition, ie. a stack template
+// which is expanded into one stack per combination of the matrix values.
+func (s *Stack) IsMatrix() bool {
+	return len(s.Matrix) > 0
+}
+
+// ExpandMatrix returns the stacks for each combination of the matrix values,
+// keyed by the name of the combination directory, relative to the matrix
+// definition directory. The combination directory name is built by joining the
+// values of the combination (in the sorted order of the matrix keys) with "-".
+func (s *Stack) ExpandMatrix(dirname string) (map[string]*Stack, error) {
+	keys := make([]string, 0, len(s.Matrix))
+	for key := range s.Matrix {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	combinations := []map[string]string{{}}
+	for _, key := range keys {
+		var next []map[string]string
+		for _, comb := range combinations {
+			for _, val := range s.Matrix[key] {
+				each := make(map[string]string, len(comb)+1)
+				for k, v := range comb {
+					each[k] = v
+				}
+				each[key] = val
+				next = append(next, each)
+			}
+		}
+		combinations = next
+	}
+
+	name := s.Name
+	if name == "" {
+		name = dirname
+	}
+
+	stacks := map[string]*Stack{}
+	for _, each := range combinations {
+		values := make([]string, len(keys))
+		for i, key := range keys {
+			values[i] = each[key]
+		}
+		combdir := strings.Join(values, "-")
+		if _, ok := stacks[combdir]; ok {
+			return nil, errors.E(ErrTerramateSchema,
+				"stack.matrix combinations conflict in the directory %q", combdir)
+		}
+
+		stack := &Stack{
+			Range:       s.Range,
+			Name:        name + "-" + combdir,
+			Description: s.Description,
+			Tags:        s.Tags,
+			After:       matrixRelPaths(s.After),
+			Before:      matrixRelPaths(s.Before),
+			Wants:       matrixRelPaths(s.Wants),
+			WantedBy:    matrixRelPaths(s.WantedBy),
+			Watch:       matrixRelPaths(s.Watch),
+			Each:        each,
+			Condition:   s.Condition,
+			AttrRanges:  s.AttrRanges,
+		}
+		if s.ID != "" {
+			stack.ID = matrixID(s.ID, combdir)
+		}
+		stacks[combdir] = stack
+	}
+	return stacks, nil
+}
+
+// matrixID returns the ID of the combination stack. IDs exceeding the
+// maximum stack ID length are truncated and suffixed with a hash of the whole
+// ID, so they are still unique.
+func matrixID(id, combdir string) string {
+	id = id + "-" + combdir
+	if len(id) <= maxMatrixIDLength {
+		return id
+	}
+	sum := sha256.Sum256([]byte(id))
+	prefix := id[:maxMatrixIDLength-matrixIDHashLength-1]
+	return prefix + "-" + hex.EncodeToString(sum[:])[:matrixIDHashLength]
+}
+
+// NewStackMatrixConfig creates the configuration of the combination stack of
+// a stack matrix. The dir doesn't need to exist.
+func NewStackMatrixConfig(dir string, stack *Stack) Config {
+	return Config{
+		absdir:   dir,
+		Stack:    stack,
+		Imported: NewTopLevelRawConfig(),
+	}
+}
+
+func (p *TerramateParser) parseStackMatrix(block *hclsyntax.Block) (map[string][]string, error) {
+	errs := errors.L()
+	if len(block.Labels) > 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.LabelRanges[0],
+			"stack.matrix must have no labels"))
+	}
+	for _, subBlock := range block.Body.Blocks {
+		errs.Append(errors.E(ErrTerramateSchema, subBlock.TypeRange,
+			"unrecognized block stack.matrix.%s", subBlock.Type))
+	}
+	if len(block.Body.Attributes) == 0 {
+		errs.Append(errors.E(ErrTerramateSchema, block.Range(),
+			"stack.matrix must declare at least one attribute"))
+	}
+
+	matrix := map[string][]string{}
+	attrs := ast.AsHCLAttributes(block.Body.Attributes)
+	for _, attr := range ast.SortRawAttributes(attrs) {
+		val, err := p.evalctx.Eval(attr.Expr)
+		if err != nil {
+			errs.Append(errors.E(ErrTerramateSchema, err,
+				"failed to evaluate stack.matrix.%s attribute", attr.Name))
+			continue
+		}
+		var values []string
+		if err := assignSet(attr, &values, val); err != nil {
+			errs.Append(err)
+			continue
+		}
+		if len(values) == 0 {
+			errs.Append(hclAttrErr(attr,
+				"stack.matrix.%s must have at least one value", attr.Name))
+			continue
+		}
+		for _, v := range values {
+			if !matrixValueRegex.MatchString(v) {
+				errs.Append(hclAttrErr(attr,
+					"stack.matrix.%s value %q must match %q", attr.Name, v,
+					matrixValueRegex.String()))
+			}
+		}
+		matrix[attr.Name] = values
+	}
+
+	if err := errs.AsError(); err != nil {
+		return nil, err
+	}
+	return matrix, nil
+}
+
+// matrixRelPaths adjusts the relative paths of the matrix definition to be
+// relative to the combination directories, which are one level deeper.
+func matrixRelPaths(paths []string) []string {
+	if paths == nil {
+		return nil
+	}
+	res := make([]string, len(paths))
+	for i, p := range paths {
+		if path.IsAbs(p) || strings.HasPrefix(p, "tag:") || strings.HasPrefix(p, "id:") {
+			res[i] = p
+			continue
+		}
+		res[i] = path.Join("..", p)
+	}
+	return res
+}
@@ -17,7 +17,6 @@ import (
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/event"
 	"github.com/terramate-io/terramate/fs"
-	"github.com/terramate-io/terramate/git"
 	"github.com/terramate-io/terramate/modvendor"
 	"github.com/terramate-io/terramate/modvendor/manifest"
 	"github.com/terramate-io/terramate/project"
@@ -424,21 +423,6 @@ func downloadVendor(
 		Str("tmTempDir", tmTempDir).
 		Logger()
 
-	logger.Trace().Msg("setting up git wrapper")
-
-	// Same strategy used on the Go toolchain:
-	// - https://github.com/golang/go/blob/2ebe77a2fda1ee9ff6fd9a3e08933ad1ebaea039/src/cmd/go/internal/get/get.go#L129
-
-	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
-	g, err := git.WithConfig(git.Config{
-		WorkingDir:     clonedRepoDir,
-		AllowPorcelain: true,
-		Env:            env,
-	})
-	if err != nil {
-		return "", err
-	}
-
 	event := event.VendorProgress{
 		Message:   "downloading",
 		TargetDir: modvendor.TargetDir(vendorDir, modsrc),
@@ -452,22 +436,10 @@ func downloadVendor(
 			Msg("dropped progress event, event handler is not fast enough or absent")
 	}
 
-	logger.Trace().Msg("cloning to workdir")
-
-	if err := g.Clone(modsrc.URL, clonedRepoDir); err != nil {
+	if err := modvendor.Clone(modsrc, clonedRepoDir); err != nil {
 		return "", err
 	}
 
-	const create = false
-
-	if err := g.Checkout(modsrc.Ref, create); err != nil {
-		return "", errors.E(err, "checking ref %s", modsrc.Ref)
-	}
-
-	if err := os.RemoveAll(filepath.Join(clonedRepoDir, ".git")); err != nil {
-		return "", errors.E(err, "removing .git dir from cloned repo")
-	}
-
 	logger.Trace().Msg("checking for manifest")
 
 	matcher, err := manifest.LoadFileMatcher(clonedRepoDir)
@@ -6,8 +6,12 @@
 package modvendor
 
 import (
+	"os"
 	"path/filepath"
 
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/git"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/tf"
 )
@@ -30,3 +34,50 @@ func SourceDir(path string, rootdir string, vendordir project.Path) string {
 func AbsVendorDir(rootdir string, vendorDir project.Path, modsrc tf.Source) string {
 	return filepath.Join(rootdir, filepath.FromSlash(TargetDir(vendorDir, modsrc).String()))
 }
+
+// Clone clones the git repository of the modsrc into the dir directory and
+// checks out the modsrc.Ref. The .git directory is removed after the checkout,
+// so the dir will only contain the files of the given reference.
+// The dir must exist and be empty. It should not be inside another git
+// repository since some git setups will assume that any git clone inside a
+// repository is a submodule.
+func Clone(modsrc tf.Source, dir string) error {
+	logger := log.With().
+		Str("action", "modvendor.Clone()").
+		Str("url", modsrc.URL).
+		Str("ref", modsrc.Ref).
+		Str("dir", dir).
+		Logger()
+
+	logger.Trace().Msg("setting up git wrapper")
+
+	// Same strategy used on the Go toolchain:
+	// - https://github.com/golang/go/blob/2ebe77a2fda1ee9ff6fd9a3e08933ad1ebaea039/src/cmd/go/internal/get/get.go#L129
+
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	g, err := git.WithConfig(git.Config{
+		WorkingDir:     dir,
+		AllowPorcelain: true,
+		Env:            env,
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Trace().Msg("cloning to workdir")
+
+	if err := g.Clone(modsrc.URL, dir); err != nil {
+		return err
+	}
+
+	const create = false
+
+	if err := g.Checkout(modsrc.Ref, create); err != nil {
+		return errors.E(err, "checking ref %s", modsrc.Ref)
+	}
+
+	if err := os.RemoveAll(filepath.Join(dir, ".git")); err != nil {
+		return errors.E(err, "removing .git dir from cloned repo")
+	}
+	return nil
+}
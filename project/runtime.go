@@ -0,0 +1,59 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package project
+
+import (
+	"path/filepath"
+
+	"github.com/zclconf/go-cty/cty"
+)
+
+// StackMetadata is the metadata of a stack exposed in the terramate namespace.
+type StackMetadata struct {
+	Dir         Path
+	Name        string
+	Description string
+	ID          string
+	Tags        []string
+
+	// ToRoot is the relative path from the stack directory to the project root.
+	ToRoot string
+}
+
+// StackRuntime returns the runtime values of the terramate namespace for the
+// stack metadata. The extra values are added to the terramate.stack object.
+func StackRuntime(meta StackMetadata, extra map[string]cty.Value) Runtime {
+	stackpath := cty.ObjectVal(map[string]cty.Value{
+		"absolute": cty.StringVal(meta.Dir.String()),
+		"relative": cty.StringVal(meta.Dir.String()[1:]),
+		"basename": cty.StringVal(filepath.Base(meta.Dir.String())),
+		"to_root":  cty.StringVal(filepath.ToSlash(meta.ToRoot)),
+	})
+	tags := cty.ListValEmpty(cty.String)
+	if len(meta.Tags) > 0 {
+		vals := make([]cty.Value, len(meta.Tags))
+		for i, tag := range meta.Tags {
+			vals[i] = cty.StringVal(tag)
+		}
+		tags = cty.ListVal(vals)
+	}
+	stackVals := map[string]cty.Value{
+		"name":        cty.StringVal(meta.Name),
+		"description": cty.StringVal(meta.Description),
+		"tags":        tags,
+		"path":        stackpath,
+	}
+	for name, val := range extra {
+		stackVals[name] = val
+	}
+	if meta.ID != "" {
+		stackVals["id"] = cty.StringVal(meta.ID)
+	}
+	return Runtime{
+		"name":        cty.StringVal(meta.Name),         // DEPRECATED
+		"path":        cty.StringVal(meta.Dir.String()), // DEPRECATED
+		"description": cty.StringVal(meta.Description),  // DEPRECATED
+		"stack":       cty.ObjectVal(stackVals),
+	}
+}
@@ -10,9 +10,12 @@ import (
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/terramate-io/terramate/hcl/info"
 	"github.com/terramate-io/terramate/stdlib"
 
+	hhcl "github.com/hashicorp/hcl/v2"
 	"github.com/rs/zerolog/log"
 	"github.com/zclconf/go-cty/cty"
 )
@@ -35,53 +38,97 @@ const (
 // on os.Environ and can be used to set env on exec.Cmd.
 type EnvVars []string
 
+// EnvVar is an environment variable of a stack and where it was defined.
+type EnvVar struct {
+	Name  string
+	Value string
+
+	// Origin is the range of the attribute defining the variable.
+	Origin info.Range
+
+	// Sensitive tells if the value is sensitive and must be redacted when
+	// displayed.
+	Sensitive bool
+}
+
+// DisplayValue returns the value to be displayed, which is redacted for
+// sensitive variables.
+func (v EnvVar) DisplayValue() string {
+	if v.Sensitive {
+		return eval.RedactedValue
+	}
+	return v.Value
+}
+
 // LoadEnv will load environment variables to be exported when running any command
 // inside the given stack. The order of the env vars is guaranteed to be the same
 // and is ordered lexicographically.
 func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
+	vars, err := LoadEnvVars(root, st)
+	if err != nil || vars == nil {
+		return nil, err
+	}
+
+	envVars := EnvVars{}
+	for _, envVar := range vars {
+		envVars = append(envVars, envVar.Name+"="+envVar.Value)
+	}
+	return envVars, nil
+}
+
+// LoadEnvVars loads the environment variables of the given stack, ordered
+// lexicographically, together with where they were defined.
+//
+// The variables are defined by the terramate.config.run.env block and by the
+// run.env blocks of the stack directory and its parent directories, where the
+// variables of a directory override the ones of its parent directories.
+func LoadEnvVars(root *config.Root, st *config.Stack) ([]EnvVar, error) {
 	logger := log.With().
-		Str("action", "run.Env()").
+		Str("action", "run.LoadEnvVars()").
 		Str("root", root.HostDir()).
 		Stringer("stack", st).
 		Logger()
 
 	logger.Trace().Msg("checking if we have run env config")
 
-	if !root.Tree().Node.HasRunEnv() {
+	attrs, found := envAttributes(root, st)
+	if !found {
 		logger.Trace().Msg("no run env config found, nothing to do")
 		return nil, nil
 	}
 
 	logger.Trace().Msg("loading globals")
 
-	globalsReport := globals.ForStack(root, st)
+	var traversals []hhcl.Traversal
+	for _, attr := range attrs {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+	globalsReport := globals.ForStackOnly(root, st, traversals)
 	if err := globalsReport.AsError(); err != nil {
 		return nil, errors.E(ErrLoadingGlobals, err)
 	}
 
-	evalctx := eval.NewContext(stdlib.Functions(st.HostDir(root)))
-	runtime := root.Runtime()
-	runtime.Merge(st.RuntimeValues(root))
-	evalctx.SetNamespace("terramate", runtime)
+	evalctx := root.NewStackEvalContext(st, stdlib.Functions(st.EvalDir(root)))
 	evalctx.SetNamespace("global", globalsReport.Globals.AsValueMap())
 	evalctx.SetEnv(os.Environ())
 
-	envVars := EnvVars{}
-
-	attrs := root.Tree().Node.Terramate.Config.Run.Env.Attributes.SortedList()
+	envVars := []EnvVar{}
 
-	for _, attr := range attrs {
+	for _, attr := range attrs.SortedList() {
 		logger = logger.With().
 			Str("attribute", attr.Name).
 			Logger()
 
 		logger.Trace().Msg("evaluating")
 
-		val, err := evalctx.Eval(attr.Expr)
+		val, err := evalctx.EvalSensitive(attr.Expr)
 		if err != nil {
 			return nil, errors.E(ErrEval, err)
 		}
 
+		sensitive := eval.IsSensitive(val)
+		val, _ = val.UnmarkDeep()
+
 		logger.Trace().Msg("checking evaluated value type")
 
 		if val.Type() != cty.String {
@@ -92,7 +139,12 @@ func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
 				val.Type().FriendlyName(),
 			)
 		}
-		envVars = append(envVars, attr.Name+"="+val.AsString())
+		envVars = append(envVars, EnvVar{
+			Name:      attr.Name,
+			Value:     val.AsString(),
+			Origin:    attr.Range,
+			Sensitive: sensitive,
+		})
 
 		logger.Trace().Msg("env var loaded")
 	}
@@ -100,6 +152,40 @@ func LoadEnv(root *config.Root, st *config.Stack) (EnvVars, error) {
 	return envVars, nil
 }
 
+// envAttributes returns the effective env attributes of the stack, from the
+// terramate.config.run.env block and the run.env blocks of the stack directory
+// and its parent directories. It returns false if no env block is found.
+func envAttributes(root *config.Root, st *config.Stack) (ast.Attributes, bool) {
+	attrs := ast.Attributes{}
+	found := false
+
+	if root.Tree().Node.HasRunEnv() {
+		found = true
+		for name, attr := range root.Tree().Node.Terramate.Config.Run.Env.Attributes {
+			attrs[name] = attr
+		}
+	}
+
+	var nodes []*config.Tree
+	node, ok := root.Lookup(st.Dir)
+	for ok && node != nil {
+		nodes = append(nodes, node)
+		node = node.Parent
+	}
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		runEnv := nodes[i].Node.RunEnv
+		if runEnv == nil {
+			continue
+		}
+		found = true
+		for name, attr := range runEnv.Attributes {
+			attrs[name] = attr
+		}
+	}
+	return attrs, found
+}
+
 func getEnv(key string, environ []string) (string, bool) {
 	for i := len(environ) - 1; i >= 0; i-- {
 		env := environ[i]
@@ -132,6 +132,77 @@ func TestLoadRunEnv(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "stacks with env from directory run.env blocks",
+			layout: []string{
+				"s:stacks/stack-1",
+				"s:stacks/stack-2",
+				"s:other",
+			},
+			configs: []hclconfig{
+				{
+					path: "/",
+					add: runEnvCfg(
+						Str("root", "root"),
+						Str("overridden", "root"),
+					),
+				},
+				{
+					path: "/stacks",
+					add: Run(Env(
+						Str("dir", "stacks"),
+						Str("overridden", "stacks"),
+					)),
+				},
+				{
+					path: "/stacks/stack-1",
+					add: Run(Env(
+						Expr("overridden", "terramate.stack.name"),
+					)),
+				},
+			},
+			want: map[string]result{
+				"stacks/stack-1": {
+					env: run.EnvVars{
+						"dir=stacks",
+						"overridden=stack-1",
+						"root=root",
+					},
+				},
+				"stacks/stack-2": {
+					env: run.EnvVars{
+						"dir=stacks",
+						"overridden=stacks",
+						"root=root",
+					},
+				},
+				"other": {
+					env: run.EnvVars{
+						"overridden=root",
+						"root=root",
+					},
+				},
+			},
+		},
+		{
+			name: "fails on unrecognized attribute in directory run block",
+			layout: []string{
+				"s:stack",
+			},
+			configs: []hclconfig{
+				{
+					path: "/stack",
+					add: Run(
+						Bool("check_gen_code", false),
+					),
+				},
+			},
+			want: map[string]result{
+				"stack": {
+					cfgerr: errors.E(hcl.ErrTerramateSchema),
+				},
+			},
+		},
 		{
 			name: "fails on invalid root config",
 			layout: []string{
@@ -249,6 +320,74 @@ func TestLoadRunEnv(t *testing.T) {
 	}
 }
 
+func TestLoadEnvVarsOrigin(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stacks/stack",
+		`f:terramate.tm:terramate {
+		  config {
+		    run {
+		      env {
+		        A = "root"
+		        B = "root"
+		      }
+		    }
+		  }
+		}`,
+		`f:stacks/env.tm:run {
+		  env {
+		    B = "stacks"
+		  }
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stacks/stack"))
+	assert.NoError(t, err)
+
+	vars, err := run.LoadEnvVars(root, st)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(vars))
+	assert.EqualStrings(t, "A", vars[0].Name)
+	assert.EqualStrings(t, "/terramate.tm", vars[0].Origin.Path().String())
+	assert.EqualStrings(t, "B", vars[1].Name)
+	assert.EqualStrings(t, "stacks", vars[1].Value)
+	assert.EqualStrings(t, "/stacks/env.tm", vars[1].Origin.Path().String())
+}
+
+func TestLoadEnvVarsSensitive(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		"s:stack",
+		`f:globals.tm:globals {
+		  token = tm_sensitive("secret")
+		}`,
+		`f:env.tm:run {
+		  env {
+		    PLAIN = "plain"
+		    TOKEN = "token=${global.token}"
+		  }
+		}`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+	st, err := config.LoadStack(root, project.NewPath("/stack"))
+	assert.NoError(t, err)
+
+	vars, err := run.LoadEnvVars(root, st)
+	assert.NoError(t, err)
+	assert.EqualInts(t, 2, len(vars))
+	assert.IsTrue(t, !vars[0].Sensitive)
+	assert.EqualStrings(t, "plain", vars[0].DisplayValue())
+	assert.IsTrue(t, vars[1].Sensitive)
+	assert.EqualStrings(t, "token=secret", vars[1].Value)
+	assert.EqualStrings(t, "(sensitive)", vars[1].DisplayValue())
+}
+
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
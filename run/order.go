@@ -14,6 +14,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run/dag"
 )
 
@@ -167,12 +168,27 @@ func BuildDAG(
 				continue
 			}
 
+			if config.IsStackIDRef(pathstr) {
+				stackPath, err := root.ResolveStackIDRef(s, fieldname, pathstr)
+				if err != nil {
+					return nil, err
+				}
+				uniqPaths[stackPath.String()] = struct{}{}
+				continue
+			}
+
 			var abspath string
 			if path.IsAbs(pathstr) {
 				abspath = filepath.Join(root.HostDir(), filepath.FromSlash(pathstr))
 			} else {
 				abspath = filepath.Join(s.HostDir(root), filepath.FromSlash(pathstr))
 			}
+			// stack matrix combinations may not exist in the file system yet.
+			node, found := root.Lookup(project.PrjAbsPath(root.HostDir(), abspath))
+			if found && node.IsStack() {
+				uniqPaths[pathstr] = struct{}{}
+				continue
+			}
 			st, err := os.Stat(abspath)
 			if err != nil {
 				log.Warn().
@@ -0,0 +1,59 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package run_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/run"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestSortWithStackIDRefs(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:a:id=stack-a;after=["id:Stack-C"]`,
+		`s:b:id=stack-b;before=["id:stack-a"]`,
+		`s:nested/c:id=stack-c`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	stacks, err := config.LoadAllStacks(root.Tree())
+	assert.NoError(t, err)
+
+	ordered, reason, err := run.Sort(root, stacks)
+	assert.NoError(t, err, reason)
+
+	want := []string{"/b", "/nested/c", "/a"}
+	assert.EqualInts(t, len(want), len(ordered))
+	for i, dir := range want {
+		assert.EqualStrings(t, dir, ordered[i].Dir().String())
+	}
+}
+
+func TestStackIDRefsUnknownID(t *testing.T) {
+	t.Parallel()
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`s:a:id=stack-a;wants=["id:unknown"]`,
+	})
+
+	root, err := config.LoadRoot(s.RootDir())
+	assert.NoError(t, err)
+
+	_, err = config.LoadAllStacks(root.Tree())
+	assert.IsError(t, err, errors.E(config.ErrStackUnknownID))
+
+	var errs *errors.List
+	assert.IsTrue(t, errors.As(err, &errs))
+	e, ok := errs.Errors()[0].(*errors.Error)
+	assert.IsTrue(t, ok)
+	assert.EqualStrings(t, "/a/"+config.DefaultFilename, e.FileRange.Filename[len(s.RootDir()):])
+}
@@ -0,0 +1,220 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/project"
+)
+
+const errListAffected errors.Kind = "listing affected stacks error"
+
+// ListAffected lists the stacks affected by changes in the given files.
+// A stack is affected by a file if the file:
+//
+//   - is inside the stack directory (but not inside a child stack).
+//   - is a directory containing the stack.
+//   - is watched by the stack.
+//   - is a globals data file loaded by the stack or any of its parent directories.
+//   - is a Terramate configuration file inherited by the stack.
+//   - is imported by the stack configuration or any of its parent directories.
+//   - is the target (or inside the target) of a symbolic link in the stack.
+//   - is inside a local module used by the stack (directly or indirectly).
+//
+// The files are project paths and they are not required to exist, so the
+// impact of deleted files can also be computed. A directory path means all
+// files inside it are considered. This method does not depend on git.
+func (m *Manager) ListAffected(files []project.Path) (*Report, error) {
+	logger := log.With().
+		Str("action", "ListAffected()").
+		Logger()
+
+	logger.Debug().Msg("Get list of all stacks.")
+
+	allstacks, err := List(m.root.Tree())
+	if err != nil {
+		return nil, errors.E(errListAffected, "searching for stacks", err)
+	}
+
+	changedFiles := func(dir string) ([]string, error) {
+		rootdir := m.root.HostDir()
+		if dir != rootdir && !strings.HasPrefix(dir, rootdir+string(filepath.Separator)) {
+			// only files inside the project can be affected.
+			return nil, nil
+		}
+		modDir := project.PrjAbsPath(rootdir, dir)
+		var changed []string
+		for _, file := range files {
+			if pathContains(file, modDir) {
+				// the whole module directory is affected.
+				changed = append(changed, ".")
+				continue
+			}
+			if pathContains(modDir, file) {
+				rel := strings.TrimPrefix(file.String(), modDir.String())
+				changed = append(changed, strings.TrimPrefix(rel, "/"))
+			}
+		}
+		return changed, nil
+	}
+
+	var affected []Entry
+	for _, entry := range allstacks {
+		st := entry.Stack
+
+		logger := logger.With().
+			Stringer("stack", st).
+			Logger()
+
+		logger.Trace().Msg("Check if stack is affected.")
+
+		reason, found := m.affectedReason(st, files)
+		if !found {
+			logger.Trace().Msg("Check for affected symlink targets.")
+
+			changed, why, err := m.stackLinksChanged(st, changedFiles)
+			if err != nil {
+				return nil, errors.E(errListAffected, "checking symlink changes", err)
+			}
+			if changed {
+				reason, found = why, true
+			}
+		}
+
+		if !found {
+			logger.Trace().Msg("Check for affected modules.")
+
+			changed, why, err := m.stackModulesChanged(st, changedFiles)
+			if err != nil {
+				return nil, errors.E(errListAffected, "checking module changes", err)
+			}
+			if changed {
+				reason, found = why, true
+			}
+		}
+
+		if found {
+			logger.Debug().
+				Str("reason", reason).
+				Msg("Stack is affected.")
+
+			st.IsChanged = true
+			affected = append(affected, Entry{
+				Stack:  st,
+				Reason: reason,
+			})
+		}
+	}
+
+	sort.Sort(EntrySlice(affected))
+
+	return newReport(m.root, RepoChecks{}, affected)
+}
+
+// affectedReason returns the reason why the stack is affected by any of the
+// files. It doesn't check module dependencies.
+func (m *Manager) affectedReason(st *config.Stack, files []project.Path) (string, bool) {
+	for _, file := range files {
+		if file != st.Dir && pathContains(file, st.Dir) {
+			return fmt.Sprintf("stack is inside the directory %q", file), true
+		}
+		if pathContains(st.Dir, file) && m.nearestStack(file) == st.Dir {
+			return fmt.Sprintf("stack contains the file %q", file), true
+		}
+	}
+
+	for _, watchFile := range st.Watch {
+		for _, file := range files {
+			if pathContains(file, watchFile) {
+				return fmt.Sprintf("stack watches the file %q", watchFile), true
+			}
+		}
+	}
+
+	if tree, ok := m.root.Lookup(st.Dir); ok {
+		for _, dataFile := range globals.DataFiles(tree) {
+			for _, file := range files {
+				if pathContains(file, dataFile) {
+					return fmt.Sprintf("stack loads the globals data file %q", dataFile), true
+				}
+			}
+		}
+	}
+
+	for _, file := range files {
+		if fs.IsTerramateFile(path.Base(file.String())) && pathContains(file.Dir(), st.Dir) {
+			return fmt.Sprintf("stack inherits the configuration file %q", file), true
+		}
+	}
+
+	cfg, found := m.root.Lookup(st.Dir)
+	for found && cfg != nil {
+		for _, imported := range cfg.Node.ImportedFiles {
+			importedPath := project.PrjAbsPath(m.root.HostDir(), imported)
+			for _, file := range files {
+				if pathContains(file, importedPath) {
+					return fmt.Sprintf(
+						"the file %q is imported by the configuration at %q",
+						importedPath, cfg.Dir(),
+					), true
+				}
+			}
+		}
+		cfg = cfg.Parent
+	}
+
+	return "", false
+}
+
+// nearestStack returns the directory of the nearest stack containing the path
+// or an empty path if the path is not inside any stack.
+func (m *Manager) nearestStack(p project.Path) project.Path {
+	checkdir := p
+	for {
+		cfg, found := m.root.Lookup(checkdir)
+		if found && cfg.IsStack() {
+			return checkdir
+		}
+		if checkdir.String() == "/" {
+			return project.Path{}
+		}
+		checkdir = checkdir.Dir()
+	}
+}
+
+// pathContains tells if the file path is equal to the dir or is inside it.
+func pathContains(dir, file project.Path) bool {
+	if dir == file || dir.String() == "/" {
+		return true
+	}
+	return strings.HasPrefix(file.String(), dir.String()+"/")
+}
+
+// NewAffectedPaths converts the given paths (relative to wd or absolute host
+// paths) into project paths. Paths outside the project rootdir are an error.
+func NewAffectedPaths(rootdir, wd string, paths []string) ([]project.Path, error) {
+	var prjpaths []project.Path
+	for _, p := range paths {
+		abspath := p
+		if !filepath.IsAbs(abspath) {
+			abspath = filepath.Join(wd, filepath.FromSlash(p))
+		}
+		abspath = filepath.Clean(abspath)
+		if abspath != rootdir && !strings.HasPrefix(abspath, rootdir+string(filepath.Separator)) {
+			return nil, errors.E(errListAffected, "path %q is outside the project", p)
+		}
+		prjpaths = append(prjpaths, project.PrjAbsPath(rootdir, abspath))
+	}
+	return prjpaths, nil
+}
@@ -0,0 +1,170 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestListAffectedStacks(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name   string
+		layout []string
+		paths  []string
+		want   []string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name: "no stacks affected",
+			layout: []string{
+				"s:stack",
+				"f:other/file.txt:data",
+			},
+			paths: []string{"/other/file.txt"},
+		},
+		{
+			name: "file inside stack",
+			layout: []string{
+				"s:stack1",
+				"s:stack2",
+				"f:stack1/main.tf:",
+			},
+			paths: []string{"/stack1/main.tf"},
+			want:  []string{"/stack1"},
+		},
+		{
+			name: "file inside child stack does not affect parent",
+			layout: []string{
+				"s:parent",
+				"s:parent/child",
+				"f:parent/child/main.tf:",
+			},
+			paths: []string{"/parent/child/main.tf"},
+			want:  []string{"/parent/child"},
+		},
+		{
+			name: "deleted file inside non-stack subdir of stack",
+			layout: []string{
+				"s:stack",
+				"d:stack/subdir",
+			},
+			paths: []string{"/stack/subdir/deleted.txt"},
+			want:  []string{"/stack"},
+		},
+		{
+			name: "directory containing stacks",
+			layout: []string{
+				"s:envs/prod/a",
+				"s:envs/prod/b",
+				"s:envs/dev/a",
+			},
+			paths: []string{"/envs/prod"},
+			want:  []string{"/envs/prod/a", "/envs/prod/b"},
+		},
+		{
+			name: "watched file",
+			layout: []string{
+				`s:stack1:watch=["/external/file.txt"]`,
+				"s:stack2",
+				"f:external/file.txt:data",
+			},
+			paths: []string{"/external/file.txt"},
+			want:  []string{"/stack1"},
+		},
+		{
+			name: "globals data file",
+			layout: []string{
+				"s:envs/prod/a",
+				"s:envs/dev/a",
+				"f:data/prod.yaml:a: 1\n",
+				"f:envs/prod/globals.tm.hcl:globals_file {\n path = \"/data/prod.yaml\"\n}\n",
+			},
+			paths: []string{"/data/prod.yaml"},
+			want:  []string{"/envs/prod/a"},
+		},
+		{
+			name: "inherited configuration file",
+			layout: []string{
+				"s:envs/prod/a",
+				"s:envs/dev/a",
+				"f:envs/prod/globals.tm.hcl:globals {\n a = 1\n}\n",
+			},
+			paths: []string{"/envs/prod/globals.tm.hcl"},
+			want:  []string{"/envs/prod/a"},
+		},
+		{
+			name: "imported file",
+			layout: []string{
+				"s:stack1",
+				"s:stack2",
+				"f:shared/globals.tm.hcl:globals {\n a = 1\n}\n",
+				"f:stack1/import.tm.hcl:import {\n source = \"/shared/globals.tm.hcl\"\n}\n",
+			},
+			paths: []string{"/shared/globals.tm.hcl"},
+			want:  []string{"/stack1"},
+		},
+		{
+			name: "nested imported file",
+			layout: []string{
+				"s:stacks/stack1",
+				"s:other",
+				"f:shared/a/globals.tm.hcl:globals {\n a = 1\n}\n",
+				"f:shared/b/import.tm.hcl:import {\n source = \"/shared/a/globals.tm.hcl\"\n}\n",
+				"f:stacks/import.tm.hcl:import {\n source = \"/shared/b/import.tm.hcl\"\n}\n",
+			},
+			paths: []string{"/shared/a/globals.tm.hcl"},
+			want:  []string{"/stacks/stack1"},
+		},
+		{
+			name: "file inside used module",
+			layout: []string{
+				"s:stack1",
+				"s:stack2",
+				"f:modules/mod1/main.tf:",
+				"f:modules/mod2/main.tf:module \"mod1\" {\n source = \"../mod1\"\n}\n",
+				"f:stack1/main.tf:module \"mod2\" {\n source = \"../modules/mod2\"\n}\n",
+			},
+			paths: []string{"/modules/mod1/main.tf"},
+			want:  []string{"/stack1"},
+		},
+		{
+			name: "multiple paths",
+			layout: []string{
+				"s:stack1",
+				"s:stack2",
+				"s:stack3",
+				"f:stack1/main.tf:",
+				"f:stack3/main.tf:",
+			},
+			paths: []string{"/stack1/main.tf", "/stack3/main.tf"},
+			want:  []string{"/stack1", "/stack3"},
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := sandbox.NoGit(t, true)
+			s.BuildTree(tc.layout)
+
+			var paths []project.Path
+			for _, p := range tc.paths {
+				paths = append(paths, project.NewPath(p))
+			}
+
+			m := stack.NewManager(s.Config(), "", "")
+			report, err := m.ListAffected(paths)
+			assert.NoError(t, err)
+			assertStacks(t, tc.want, report.Stacks, true)
+		})
+	}
+}
@@ -0,0 +1,275 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	stdjson "encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/project"
+)
+
+// MaxChangeCacheEntries is the maximum number of change detection results
+// kept in the cache directory. The least recently used ones are removed first.
+const MaxChangeCacheEntries = 64
+
+// changeCache is an on-disk cache of change detection results.
+// The results are keyed by the base commit, the head commit, the hash of the
+// Terramate and Terraform files of the project and the Terramate version, so
+// any change to them automatically invalidates the cached entry.
+type changeCache struct {
+	dir  string
+	file string
+	key  changeCacheKey
+}
+
+type changeCacheKey struct {
+	Version    string `json:"version"`
+	Rootdir    string `json:"rootdir"`
+	Base       string `json:"base"`
+	Head       string `json:"head"`
+	ConfigHash string `json:"config_hash"`
+}
+
+type changeCacheData struct {
+	changeCacheKey
+	Stacks  []changeCacheEntry `json:"stacks"`
+	Missing []string           `json:"missing"`
+}
+
+type changeCacheEntry struct {
+	Stack  *config.Stack `json:"stack"`
+	Reason string        `json:"reason"`
+}
+
+// EnableChangeCache enables caching the results of [Manager.ListChanged] in
+// the given directory. The cache is disabled by default and it's only used
+// when the result doesn't depend on uncommitted or untracked files.
+func (m *Manager) EnableChangeCache(dir string) {
+	m.cacheDir = dir
+}
+
+func newChangeCache(dir string, key changeCacheKey) (*changeCache, error) {
+	data, err := stdjson.Marshal(key)
+	if err != nil {
+		return nil, errors.E(err, "computing change cache key")
+	}
+	sum := sha256.Sum256(data)
+	return &changeCache{
+		dir:  dir,
+		file: filepath.Join(dir, hex.EncodeToString(sum[:])+".json"),
+		key:  key,
+	}, nil
+}
+
+// load loads the cached stacks and the missing stacks. It returns false if
+// there's no valid entry.
+func (c *changeCache) load() ([]Entry, []project.Path, bool) {
+	logger := log.With().
+		Str("action", "changeCache.load()").
+		Str("file", c.file).
+		Logger()
+
+	data, err := os.ReadFile(c.file)
+	if err != nil {
+		logger.Trace().Err(err).Msg("No cached change detection result.")
+		return nil, nil, false
+	}
+
+	var cached changeCacheData
+	if err := stdjson.Unmarshal(data, &cached); err != nil || cached.changeCacheKey != c.key {
+		logger.Debug().Err(err).Msg("Removing invalid change detection cache entry.")
+		_ = os.Remove(c.file)
+		return nil, nil, false
+	}
+
+	entries := make([]Entry, 0, len(cached.Stacks))
+	for _, e := range cached.Stacks {
+		if e.Stack == nil {
+			_ = os.Remove(c.file)
+			return nil, nil, false
+		}
+		entries = append(entries, Entry{
+			Stack:  e.Stack,
+			Reason: e.Reason,
+		})
+	}
+
+	var missing []project.Path
+	for _, dir := range cached.Missing {
+		missing = append(missing, project.NewPath(dir))
+	}
+
+	// keep track of the most recently used entries.
+	now := time.Now()
+	_ = os.Chtimes(c.file, now, now)
+
+	logger.Debug().Msg("Using cached change detection result.")
+	return entries, missing, true
+}
+
+// store saves the stacks and the missing stacks in the cache and prunes old
+// entries.
+func (c *changeCache) store(entries []Entry, missing []project.Path) error {
+	cached := changeCacheData{
+		changeCacheKey: c.key,
+		Stacks:         make([]changeCacheEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		cached.Stacks = append(cached.Stacks, changeCacheEntry{
+			Stack:  e.Stack,
+			Reason: e.Reason,
+		})
+	}
+	for _, dir := range missing {
+		cached.Missing = append(cached.Missing, dir.String())
+	}
+
+	data, err := stdjson.Marshal(cached)
+	if err != nil {
+		return errors.E(err, "encoding change detection cache")
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return errors.E(err, "creating change detection cache dir")
+	}
+
+	tmp, err := os.CreateTemp(c.dir, ".tmp-*")
+	if err != nil {
+		return errors.E(err, "creating change detection cache file")
+	}
+
+	_, err = tmp.Write(data)
+	errClose := tmp.Close()
+	if err == nil {
+		err = errClose
+	}
+	if err == nil {
+		// rename is atomic, so concurrent readers never see partial files.
+		err = os.Rename(tmp.Name(), c.file)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.E(err, "writing change detection cache file")
+	}
+
+	return c.prune()
+}
+
+// prune removes the least recently used entries exceeding MaxChangeCacheEntries.
+func (c *changeCache) prune() error {
+	dirEntries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return errors.E(err, "reading change detection cache dir")
+	}
+
+	type cacheFile struct {
+		path    string
+		modTime time.Time
+	}
+
+	var files []cacheFile
+	for _, d := range dirEntries {
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			continue
+		}
+		info, err := d.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{
+			path:    filepath.Join(c.dir, d.Name()),
+			modTime: info.ModTime(),
+		})
+	}
+
+	if len(files) <= MaxChangeCacheEntries {
+		return nil
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.After(files[j].modTime)
+	})
+
+	for _, f := range files[MaxChangeCacheEntries:] {
+		_ = os.Remove(f.path)
+	}
+	return nil
+}
+
+// changeCacheFor returns the change cache for the given commits, or nil if
+// the cache is disabled.
+func (m *Manager) changeCacheFor(baseCommit, headCommit string) (*changeCache, error) {
+	if m.cacheDir == "" {
+		return nil, nil
+	}
+
+	// files ignored by git can still change the configuration and the
+	// module sources of the stacks.
+	hash, err := configHash(m.root.HostDir())
+	if err != nil {
+		return nil, err
+	}
+
+	return newChangeCache(m.cacheDir, changeCacheKey{
+		Version:    terramate.Version(),
+		Rootdir:    m.root.HostDir(),
+		Base:       baseCommit,
+		Head:       headCommit,
+		ConfigHash: hash,
+	})
+}
+
+// configHash computes a hash of all the files inside the rootdir which are
+// read by the change detection, besides the git history: the Terramate
+// configuration files, which define the stacks, their watch files and
+// globals data files, and the Terraform files, which define the local module
+// sources of the stacks.
+func configHash(rootdir string) (string, error) {
+	h := sha256.New()
+	err := filepath.WalkDir(rootdir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path != rootdir && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() ||
+			(!fs.IsTerramateFile(d.Name()) && filepath.Ext(d.Name()) != ".tf") {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+
+		relpath, _ := filepath.Rel(rootdir, path)
+		_, _ = io.WriteString(h, filepath.ToSlash(relpath))
+		_, _ = h.Write([]byte{0})
+		_, err = io.Copy(h, f)
+		_, _ = h.Write([]byte{0})
+		return err
+	})
+	if err != nil {
+		return "", errors.E(err, "computing configuration hash")
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,145 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	stdjson "encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test"
+)
+
+func TestListChangedStacksCache(t *testing.T) {
+	t.Parallel()
+
+	repo := multipleStacksOneChangedRepo(t)
+	cachedir := t.TempDir()
+
+	listChanged := func() *stack.Report {
+		m := newManager(t, repo.Dir)
+		m.EnableChangeCache(cachedir)
+		report, err := m.ListChanged()
+		assert.NoError(t, err)
+		assertStacks(t, []string{"/changed-stack"}, report.Stacks, true)
+		return report
+	}
+
+	report := listChanged()
+	assert.IsTrue(t, report.Stacks[0].Reason != "cached")
+
+	files := cacheFiles(t, cachedir)
+	assert.EqualInts(t, 1, len(files))
+
+	setCachedReason(t, files[0], "cached")
+
+	report = listChanged()
+	assert.EqualStrings(t, "cached", report.Stacks[0].Reason)
+
+	t.Run("corrupted cache entries are ignored", func(t *testing.T) {
+		test.WriteFile(t, cachedir, filepath.Base(files[0]), "not json")
+
+		report := listChanged()
+		assert.IsTrue(t, report.Stacks[0].Reason != "cached")
+		assert.EqualInts(t, 1, len(cacheFiles(t, cachedir)))
+	})
+
+	t.Run("changes in git ignored configuration invalidate the cache", func(t *testing.T) {
+		setCachedReason(t, files[0], "cached")
+
+		test.WriteFile(t, repo.Dir, ".git/info/exclude", "ignored.tm.hcl\n")
+		test.WriteFile(t, repo.Dir, "ignored.tm.hcl", "globals {\n  a = 1\n}\n")
+
+		report := listChanged()
+		assert.IsTrue(t, report.Stacks[0].Reason != "cached")
+		assert.EqualInts(t, 2, len(cacheFiles(t, cachedir)))
+	})
+
+	t.Run("changes in git ignored terraform files invalidate the cache", func(t *testing.T) {
+		files := cacheFiles(t, cachedir)
+		for _, file := range files {
+			setCachedReason(t, file, "cached")
+		}
+
+		test.WriteFile(t, repo.Dir, ".git/info/exclude", "ignored.tm.hcl\nignored.tf\n")
+		test.WriteFile(t, repo.Dir, "ignored.tf", "# module sources\n")
+
+		report := listChanged()
+		assert.IsTrue(t, report.Stacks[0].Reason != "cached")
+		assert.EqualInts(t, len(files)+1, len(cacheFiles(t, cachedir)))
+	})
+
+	t.Run("untracked files disable the cache", func(t *testing.T) {
+		test.WriteFile(t, repo.Dir, "untracked.txt", "")
+
+		emptydir := t.TempDir()
+		m := newManager(t, repo.Dir)
+		m.EnableChangeCache(emptydir)
+		_, err := m.ListChanged()
+		assert.NoError(t, err)
+		assert.EqualInts(t, 0, len(cacheFiles(t, emptydir)))
+	})
+}
+
+func cacheFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.NoError(t, err)
+	return files
+}
+
+func setCachedReason(t *testing.T, file string, reason string) {
+	t.Helper()
+
+	data, err := os.ReadFile(file)
+	assert.NoError(t, err)
+
+	var cached map[string]interface{}
+	assert.NoError(t, stdjson.Unmarshal(data, &cached))
+
+	for _, entry := range cached["stacks"].([]interface{}) {
+		entry.(map[string]interface{})["reason"] = reason
+	}
+
+	data, err = stdjson.Marshal(cached)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(file, data, 0644))
+}
+
+func TestListChangedStacksCacheUsesWorkingTreeConditions(t *testing.T) {
+	t.Parallel()
+
+	repo := singleMergeCommitRepoNoStack(t)
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+
+	test.WriteFile(t, repo.Dir, "stack/stack.tm.hcl", "stack {\n  condition = false\n}\n")
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("add disabled stack"))
+	assert.NoError(t, g.Push("origin", "main"))
+
+	assert.NoError(t, g.Checkout("testbranch", true))
+	test.WriteFile(t, repo.Dir, "stack/stack.tm.hcl", "stack {\n  condition = true\n}\n")
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("enable stack"))
+	assert.NoError(t, g.Checkout("main", false))
+
+	cachedir := t.TempDir()
+	for i := 0; i < 2; i++ {
+		root, err := config.LoadRoot(repo.Dir)
+		assert.NoError(t, err)
+		m := stack.NewManager(root, defaultBranch, "testbranch")
+		m.EnableChangeCache(cachedir)
+
+		report, err := m.ListChanged()
+		assert.NoError(t, err)
+		assert.EqualInts(t, 1, len(cacheFiles(t, cachedir)))
+		assertStacks(t, []string{}, report.Stacks, false)
+		assertStacks(t, []string{"/stack"}, report.Disabled, false)
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"fmt"
+
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl/info"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ErrCondition indicates that the stack.condition failed to evaluate.
+const ErrCondition errors.Kind = "evaluating stack condition"
+
+// IsEnabled evaluates the stack.condition using the stack globals and
+// metadata. A stack without a condition is always enabled. If the stack is
+// disabled, the returned reason tells which condition disabled it.
+func IsEnabled(root *config.Root, st *config.Stack) (enabled bool, reason string, err error) {
+	tree, ok := root.Lookup(st.Dir)
+	if !ok || !tree.IsStack() || tree.Node.Stack.Condition == nil {
+		return true, "", nil
+	}
+
+	cond := tree.Node.Stack.Condition
+
+	report := globals.ForStackOnly(root, st, cond.Variables())
+	if err := report.AsError(); err != nil {
+		return false, "", errors.E(ErrCondition, err, "loading globals of stack %s", st.Dir)
+	}
+
+	evalctx := NewEvalCtx(root, st, report.Globals)
+	val, err := evalctx.Eval(cond)
+	if err != nil {
+		return false, "", errors.E(ErrCondition, cond.Range(), err)
+	}
+
+	if val.Type() != cty.Bool || val.IsNull() || !val.IsKnown() {
+		return false, "", errors.E(ErrCondition, cond.Range(),
+			"stack.condition must be a boolean but got %s", val.Type().FriendlyName())
+	}
+
+	if val.True() {
+		return true, "", nil
+	}
+	return false, fmt.Sprintf("stack disabled by the condition at %s",
+		info.NewRange(root.HostDir(), cond.Range())), nil
+}
+
+// filterEnabled splits the entries in the enabled and the disabled ones. The
+// reason of the disabled entries tells why they are disabled.
+func filterEnabled(root *config.Root, entries []Entry) (enabled, disabled []Entry, err error) {
+	enabled = []Entry{}
+	for _, entry := range entries {
+		ok, reason, err := IsEnabled(root, entry.Stack)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			enabled = append(enabled, entry)
+			continue
+		}
+		disabled = append(disabled, Entry{
+			Stack:  entry.Stack,
+			Reason: reason,
+		})
+	}
+	return enabled, disabled, nil
+}
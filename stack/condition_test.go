@@ -0,0 +1,72 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/stack"
+	errtest "github.com/terramate-io/terramate/test/errors"
+	"github.com/terramate-io/terramate/test/sandbox"
+)
+
+func TestStackCondition(t *testing.T) {
+	t.Parallel()
+
+	s := sandbox.NoGit(t, true)
+	s.BuildTree([]string{
+		`f:terramate.tm.hcl:globals {
+		  decommissioned = ["eu-west-1"]
+		}`,
+		`f:eu-west-1/stack.tm.hcl:stack {
+		  condition = !tm_contains(global.decommissioned, terramate.stack.name)
+		}`,
+		`f:us-east-1/stack.tm.hcl:stack {
+		  wants     = ["/eu-west-1"]
+		  condition = !tm_contains(global.decommissioned, terramate.stack.name)
+		}`,
+		"s:other",
+	})
+
+	m := stack.NewManager(s.Config(), "main", "")
+	report, err := m.List()
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/other", "/us-east-1"}, report.Stacks, false)
+	assertStacks(t, []string{"/eu-west-1"}, report.Disabled, true)
+	if !strings.Contains(report.Disabled[0].Reason, "/eu-west-1/stack.tm.hcl") {
+		t.Fatalf("reason %q does not contain the condition file", report.Disabled[0].Reason)
+	}
+
+	t.Run("wanted disabled stacks are not selected", func(t *testing.T) {
+		selected, err := m.AddWantedOf(config.List[*config.SortableStack]{
+			report.Stacks[1].Stack.Sortable(),
+		})
+		assert.NoError(t, err)
+		assert.EqualInts(t, 1, len(selected))
+		assert.EqualStrings(t, "/us-east-1", selected[0].Dir().String())
+	})
+}
+
+func TestStackConditionErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, cond := range []string{
+		`"yes"`,
+		`global.undefined`,
+	} {
+		s := sandbox.NoGit(t, true)
+		s.BuildTree([]string{
+			`f:stack/stack.tm.hcl:stack {
+			  condition = ` + cond + `
+			}`,
+		})
+
+		_, err := stack.NewManager(s.Config(), "main", "").List()
+		errtest.Assert(t, err, errors.E(stack.ErrCondition))
+	}
+}
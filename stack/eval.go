@@ -4,7 +4,12 @@
 package stack
 
 import (
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/globals"
+	"github.com/terramate-io/terramate/hcl"
+	"github.com/terramate-io/terramate/hcl/ast"
 	"github.com/terramate-io/terramate/hcl/eval"
 	"github.com/terramate-io/terramate/stdlib"
 )
@@ -18,12 +23,10 @@ type EvalCtx struct {
 
 // NewEvalCtx creates a new stack evaluation context.
 func NewEvalCtx(root *config.Root, stack *config.Stack, globals *eval.Object) *EvalCtx {
-	evalctx := eval.NewContext(stdlib.Functions(stack.HostDir(root)))
 	evalwrapper := &EvalCtx{
-		Context: evalctx,
+		Context: root.NewStackEvalContext(stack, stdlib.Functions(stack.EvalDir(root))),
 		root:    root,
 	}
-	evalwrapper.SetMetadata(stack)
 	evalwrapper.SetGlobals(globals)
 	return evalwrapper
 }
@@ -33,9 +36,30 @@ func (e *EvalCtx) SetGlobals(g *eval.Object) {
 	e.SetNamespace("global", g.AsValueMap())
 }
 
-// SetMetadata sets the given metadata in the stack evaluation context.
-func (e *EvalCtx) SetMetadata(st *config.Stack) {
-	runtime := e.root.Runtime()
-	runtime.Merge(st.RuntimeValues(e.root))
-	e.SetNamespace("terramate", runtime)
+// SetStacksNamespace sets the stacks namespace with the globals and metadata
+// of the stacks referenced by the content, lets, condition and asserts of a
+// generate block.
+func (e *EvalCtx) SetStacksNamespace(
+	content hclsyntax.Node,
+	lets *ast.MergedBlock,
+	condition *hclsyntax.Attribute,
+	asserts []hcl.AssertConfig,
+) error {
+	nodes := []hclsyntax.Node{content}
+	if lets != nil {
+		for _, block := range lets.RawOrigins {
+			nodes = append(nodes, block.Block)
+		}
+	}
+	if condition != nil {
+		nodes = append(nodes, condition)
+	}
+	for _, assert := range asserts {
+		for _, expr := range []hhcl.Expression{assert.Assertion, assert.Message, assert.Warning} {
+			if node, ok := expr.(hclsyntax.Node); ok {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	return globals.SetStacksNamespace(e.Context, e.root, globals.StacksTraversals(nodes...))
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/terramate-io/terramate/config"
 	"github.com/terramate-io/terramate/errors"
 	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/globals"
 	"github.com/terramate-io/terramate/project"
 	"github.com/terramate-io/terramate/run"
 	"github.com/terramate-io/terramate/run/dag"
@@ -28,14 +29,29 @@ type (
 	Manager struct {
 		root       *config.Root // whole config
 		gitBaseRef string       // gitBaseRef is the git ref where we compare changes.
+		gitHeadRef string       // gitHeadRef is the git ref with the changes.
 
-		outerGit *git.Git
+		outerGit    *git.Git
+		gitToplevel string // gitToplevel is the root dir of the project repository.
+
+		cacheDir     string              // cacheDir is where change detection results are cached.
+		changedFiles map[string][]string // changedFiles memoizes the changed files per dir.
 	}
 
 	// Report is the report of project's stacks and the result of its default checks.
 	Report struct {
 		Stacks []Entry
 
+		// Disabled contains the stacks disabled by their stack.condition.
+		// The entry reason tells why the stack is disabled.
+		Disabled []Entry
+
+		// Missing contains the stacks changed in the head revision which
+		// don't exist in the working tree. They are only reported when the
+		// head revision is not checked out, as the stacks are always loaded
+		// and run from the working tree.
+		Missing []project.Path
+
 		// Checks contains the result info of default checks.
 		Checks RepoChecks
 	}
@@ -56,12 +72,21 @@ type (
 const errList errors.Kind = "listing stacks error"
 const errListChanged errors.Kind = "listing changed stacks error"
 
-// NewManager creates a new stack manager.The root is the project root config
-// and and gitBaseRef is the git reference to compare for changes.
-func NewManager(root *config.Root, gitBaseRef string) *Manager {
+// DefaultHeadRef is the git reference used as head when none is provided.
+const DefaultHeadRef = "HEAD"
+
+// NewManager creates a new stack manager. The root is the project root config,
+// the gitBaseRef is the git reference to compare for changes and the
+// gitHeadRef is the git reference containing the changes. If gitHeadRef is
+// empty then DefaultHeadRef is used.
+func NewManager(root *config.Root, gitBaseRef, gitHeadRef string) *Manager {
+	if gitHeadRef == "" {
+		gitHeadRef = DefaultHeadRef
+	}
 	return &Manager{
 		root:       root,
 		gitBaseRef: gitBaseRef,
+		gitHeadRef: gitHeadRef,
 	}
 }
 
@@ -79,8 +104,9 @@ func (m *Manager) List() (*Report, error) {
 		return nil, err
 	}
 
-	report := &Report{
-		Stacks: entries,
+	report, err := newReport(m.root, RepoChecks{}, entries)
+	if err != nil {
+		return nil, errors.E(errList, err)
 	}
 
 	logger.Trace().Str("repo", m.root.HostDir()).
@@ -149,9 +175,100 @@ func (m *Manager) ListChanged() (*Report, error) {
 		return nil, errors.E(errListChanged, err)
 	}
 
+	logger.Trace().Msg("Get commit id of git head ref.")
+
+	headCommit, err := g.RevParse(m.gitHeadRef)
+	if err != nil {
+		return nil, errors.E(errListChanged, err, "getting revision %q", m.gitHeadRef)
+	}
+
+	currentCommit, err := g.RevParse(DefaultHeadRef)
+	if err != nil {
+		return nil, errors.E(errListChanged, err, "getting HEAD revision")
+	}
+
+	baseCommit, err := g.RevParse(m.gitBaseRef)
+	if err != nil {
+		return nil, errors.E(errListChanged, err, "getting revision %q", m.gitBaseRef)
+	}
+
+	// the stacks are always loaded from the working tree, then uncommitted
+	// and untracked files can change the result.
+	var cache *changeCache
+	if len(checks.UncommittedFiles) == 0 && len(checks.UntrackedFiles) == 0 {
+		cache, err = m.changeCacheFor(baseCommit, headCommit)
+		if err != nil {
+			return nil, errors.E(errListChanged, err)
+		}
+	}
+
+	if cache != nil {
+		if stacks, missing, ok := cache.load(); ok {
+			report, err := newReport(m.root, checks, stacks)
+			if err != nil {
+				return nil, errors.E(errListChanged, err)
+			}
+			report.Missing = missing
+			return report, nil
+		}
+	}
+
+	// the stacks are loaded from the working tree, then the stacks created
+	// in the head revision can't be run and are reported as missing.
+	var missing []project.Path
+	if headCommit != currentCommit {
+		missing, err = m.listMissingStacks(g, headCommit)
+		if err != nil {
+			return nil, errors.E(errListChanged, err)
+		}
+	}
+
+	stacks, err := m.listChanged(missing)
+	if err != nil {
+		return nil, err
+	}
+
+	if cache != nil {
+		if err := cache.store(stacks, missing); err != nil {
+			logger.Warn().Err(err).Msg("failed to cache change detection result")
+		}
+	}
+
+	report, err := newReport(m.root, checks, stacks)
+	if err != nil {
+		return nil, errors.E(errListChanged, err)
+	}
+	report.Missing = missing
+	return report, nil
+}
+
+// newReport creates a report of the given entries, moving the stacks disabled
+// by their stack.condition to the report Disabled entries.
+func newReport(root *config.Root, checks RepoChecks, entries []Entry) (*Report, error) {
+	enabled, disabled, err := filterEnabled(root, entries)
+	if err != nil {
+		return nil, err
+	}
+	return &Report{
+		Stacks:   enabled,
+		Disabled: disabled,
+		Checks:   checks,
+	}, nil
+}
+
+func (m *Manager) listChanged(missing []project.Path) ([]Entry, error) {
+	logger := log.With().
+		Str("action", "listChanged()").
+		Logger()
+
 	logger.Debug().Msg("List changed files.")
 
-	changedFiles, err := m.listChangedFiles(m.root.HostDir(), m.gitBaseRef)
+	changedFiles, err := m.listChangedFiles(m.root.HostDir())
+	if err != nil {
+		return nil, errors.E(errListChanged, err)
+	}
+
+	changedFiles, err = m.expandSubmodules(changedFiles)
 	if err != nil {
 		return nil, errors.E(errListChanged, err)
 	}
@@ -172,6 +289,33 @@ func (m *Manager) ListChanged() (*Report, error) {
 			continue
 		}
 
+		if isInsideStacks(projpath, missing) {
+			logger.Debug().Msg("ignoring changed file of stack missing in the working tree")
+			continue
+		}
+
+		if st, err := os.Stat(abspath); err == nil && st.IsDir() {
+			// a changed directory is a submodule with unknown changes, then
+			// all stacks inside it are changed.
+			logger.Debug().Msg("submodule change detected")
+
+			cfg, found := m.root.Lookup(projpath)
+			if found {
+				for _, stackTree := range cfg.Stacks() {
+					s, err := config.NewStackFromHCL(m.root.HostDir(), stackTree.Node)
+					if err != nil {
+						return nil, errors.E(errListChanged, err)
+					}
+
+					stackSet[s.Dir] = Entry{
+						Stack:  s,
+						Reason: fmt.Sprintf("stack is inside the changed submodule %q", projpath),
+					}
+				}
+			}
+			continue
+		}
+
 		if isTriggerFile {
 			logger = logger.With().
 				Stringer("trigger", triggeredStack).
@@ -214,9 +358,15 @@ func (m *Manager) ListChanged() (*Report, error) {
 			Str("path", dirname).
 			Msg("Try load changed.")
 
+		// a change inside a stack matrix definition directory changes all of
+		// its combinations.
+		ownsChange := func(tree *config.Tree) bool {
+			return tree.IsStack() || tree.IsStackMatrix()
+		}
+
 		cfgpath := project.PrjAbsPath(m.root.HostDir(), dirname)
 		stackTree, found := m.root.Lookup(cfgpath)
-		if !found || !stackTree.IsStack() {
+		if !found || !ownsChange(stackTree) {
 			logger.Debug().
 				Str("path", dirname).
 				Msg("Lookup parent stack.")
@@ -225,15 +375,35 @@ func (m *Manager) ListChanged() (*Report, error) {
 			for checkdir.String() != "/" {
 				checkdir = checkdir.Dir()
 				stackTree, found = m.root.Lookup(checkdir)
-				if found && stackTree.IsStack() {
+				if found && ownsChange(stackTree) {
 					break
 				}
 			}
-			if !found || !stackTree.IsStack() {
+			if !found || !ownsChange(stackTree) {
 				continue
 			}
 		}
 
+		if stackTree.IsStackMatrix() {
+			for _, child := range stackTree.Children {
+				if !child.IsStack() || child.Node.Stack.Each == nil {
+					continue
+				}
+				s, err := config.NewStackFromHCL(m.root.HostDir(), child.Node)
+				if err != nil {
+					return nil, errors.E(errListChanged, err)
+				}
+				if _, ok := stackSet[s.Dir]; ok {
+					continue
+				}
+				stackSet[s.Dir] = Entry{
+					Stack:  s,
+					Reason: "stack matrix definition has unmerged changes",
+				}
+			}
+			continue
+		}
+
 		s, err := config.NewStackFromHCL(m.root.HostDir(), stackTree.Node)
 		if err != nil {
 			return nil, errors.E(errListChanged, err)
@@ -284,68 +454,64 @@ rangeStacks:
 
 		logger.Debug().
 			Stringer("stack", stack).
-			Msg("Apply function to stack.")
-
-		err := m.filesApply(stack.HostDir(m.root), func(file fs.DirEntry) error {
-			if path.Ext(file.Name()) != ".tf" {
-				return nil
-			}
+			Msg("Check for changed globals data files.")
 
+		if changed, ok := m.hasChangedGlobalsDataFiles(stack, changedFiles); ok {
 			logger.Debug().
 				Stringer("stack", stack).
-				Msg("Get tf file path.")
-
-			tfpath := filepath.Join(stack.HostDir(m.root), file.Name())
-
-			logger.Trace().
-				Stringer("stack", stack).
-				Str("configFile", tfpath).
-				Msg("Parse modules.")
+				Stringer("datafile", changed).
+				Msg("changed.")
 
-			modules, err := tf.ParseModules(tfpath)
-			if err != nil {
-				return errors.E(errListChanged, "parsing modules", err)
+			stack.IsChanged = true
+			stackSet[stack.Dir] = Entry{
+				Stack: stack,
+				Reason: fmt.Sprintf(
+					"stack changed because globals data file %q changed",
+					changed,
+				),
 			}
+			continue rangeStacks
+		}
 
-			logger.Trace().
-				Stringer("stack", stack).
-				Str("configFile", tfpath).
-				Msg("Range over modules.")
+		if _, err := os.Stat(stack.HostDir(m.root)); errors.Is(err, fs.ErrNotExist) {
+			// stack matrix combinations may not exist in the file system yet.
+			continue
+		}
 
-			for _, mod := range modules {
-				logger.Trace().
-					Stringer("stack", stack).
-					Str("configFile", tfpath).
-					Msg("Check if module changed.")
+		logger.Debug().
+			Stringer("stack", stack).
+			Msg("Check for changed symlink targets.")
 
-				changed, why, err := m.moduleChanged(mod, stack.HostDir(m.root), make(map[string]bool))
-				if err != nil {
-					return errors.E(errListChanged, err, "checking module %q", mod.Source)
-				}
+		changed, why, err := m.stackLinksChanged(stack, m.listChangedFiles)
+		if err != nil {
+			return nil, errors.E(errListChanged, "checking symlink changes", err)
+		}
 
-				if changed {
-					logger.Debug().
-						Stringer("stack", stack).
-						Str("configFile", tfpath).
-						Msg("Module changed.")
-
-					stack.IsChanged = true
-					stackSet[stack.Dir] = Entry{
-						Stack: stack,
-						Reason: fmt.Sprintf(
-							"stack changed because %q changed because %s",
-							mod.Source, why,
-						),
-					}
-					return nil
-				}
+		if changed {
+			stack.IsChanged = true
+			stackSet[stack.Dir] = Entry{
+				Stack:  stack,
+				Reason: why,
 			}
-			return nil
-		})
+			continue rangeStacks
+		}
 
+		logger.Debug().
+			Stringer("stack", stack).
+			Msg("Check for changed modules.")
+
+		changed, why, err = m.stackModulesChanged(stack, m.listChangedFiles)
 		if err != nil {
 			return nil, errors.E(errListChanged, "checking module changes", err)
 		}
+
+		if changed {
+			stack.IsChanged = true
+			stackSet[stack.Dir] = Entry{
+				Stack:  stack,
+				Reason: why,
+			}
+		}
 	}
 
 	logger.Trace().Msg("Make set of changed stacks.")
@@ -359,10 +525,7 @@ rangeStacks:
 
 	sort.Sort(EntrySlice(changedStacks))
 
-	return &Report{
-		Checks: checks,
-		Stacks: changedStacks,
-	}, nil
+	return changedStacks, nil
 }
 
 // AddWantedOf returns all wanted stacks from the given stacks.
@@ -418,13 +581,23 @@ func (m *Manager) AddWantedOf(scopeStacks config.List[*config.SortableStack]) (c
 
 	var selectedStacks config.List[*config.SortableStack]
 	visited = dag.Visited{}
+	var addErr error
 	addStack := func(s *config.Stack) {
 		if _, ok := visited[dag.ID(s.Dir.String())]; ok {
 			return
 		}
 
 		visited[dag.ID(s.Dir.String())] = struct{}{}
-		selectedStacks = append(selectedStacks, s.Sortable())
+
+		// disabled stacks are never selected, even if wanted.
+		enabled, _, err := IsEnabled(m.root, s)
+		if err != nil {
+			addErr = errors.L(addErr, err).AsError()
+			return
+		}
+		if enabled {
+			selectedStacks = append(selectedStacks, s.Sortable())
+		}
 	}
 
 	var pending []dag.ID
@@ -447,9 +620,211 @@ func (m *Manager) AddWantedOf(scopeStacks config.List[*config.SortableStack]) (c
 			}
 		}
 	}
+	if addErr != nil {
+		return nil, addErr
+	}
 	return selectedStacks, nil
 }
 
+// stackModulesChanged checks if any of the local modules used by the stack has
+// changed. The changedFiles function returns the list of changed files inside a
+// module directory.
+func (m *Manager) stackModulesChanged(
+	stack *config.Stack, changedFiles func(dir string) ([]string, error),
+) (changed bool, why string, err error) {
+	logger := log.With().
+		Str("action", "stackModulesChanged()").
+		Stringer("stack", stack).
+		Logger()
+
+	logger.Debug().Msg("Apply function to stack.")
+
+	err = m.filesApply(stack.HostDir(m.root), func(file fs.DirEntry) error {
+		if changed {
+			return nil
+		}
+		if path.Ext(file.Name()) != ".tf" {
+			return nil
+		}
+
+		logger.Debug().Msg("Get tf file path.")
+
+		tfpath := filepath.Join(stack.HostDir(m.root), file.Name())
+
+		logger.Trace().
+			Str("configFile", tfpath).
+			Msg("Parse modules.")
+
+		modules, err := tf.ParseModules(tfpath)
+		if err != nil {
+			return errors.E(errListChanged, "parsing modules", err)
+		}
+
+		logger.Trace().
+			Str("configFile", tfpath).
+			Msg("Range over modules.")
+
+		for _, mod := range modules {
+			logger.Trace().
+				Str("configFile", tfpath).
+				Msg("Check if module changed.")
+
+			modChanged, modWhy, err := m.moduleChanged(
+				mod, stack.HostDir(m.root), make(map[string]bool), changedFiles,
+			)
+			if err != nil {
+				return errors.E(errListChanged, err, "checking module %q", mod.Source)
+			}
+
+			if modChanged {
+				logger.Debug().
+					Str("configFile", tfpath).
+					Msg("Module changed.")
+
+				changed = true
+				why = fmt.Sprintf(
+					"stack changed because %q changed because %s",
+					mod.Source, modWhy,
+				)
+				return nil
+			}
+		}
+		return nil
+	})
+	return changed, why, err
+}
+
+// stackLinksChanged checks if the target of any symbolic link inside the stack
+// directory has changed. The changedFiles function returns the list of changed
+// files inside a directory.
+func (m *Manager) stackLinksChanged(
+	stack *config.Stack, changedFiles func(dir string) ([]string, error),
+) (changed bool, why string, err error) {
+	logger := log.With().
+		Str("action", "stackLinksChanged()").
+		Stringer("stack", stack).
+		Logger()
+
+	links, err := m.stackLinks(stack)
+	if err != nil {
+		return false, "", err
+	}
+
+	for _, link := range links {
+		logger.Trace().
+			Str("link", link.path).
+			Str("target", link.target).
+			Msg("Check if link target changed.")
+
+		dir, name := link.target, ""
+		if !link.isDir {
+			dir, name = filepath.Dir(link.target), filepath.Base(link.target)
+		}
+
+		files, err := changedFiles(dir)
+		if err != nil {
+			return false, "", errors.E(err, "listing changes in the link target %q", link.target)
+		}
+
+		for _, file := range files {
+			// "." means the whole directory changed.
+			if name == "" || file == name || file == "." {
+				return true, fmt.Sprintf(
+					"stack changed because the link %q resolves to %q which changed",
+					m.friendlyHostPath(link.path), m.friendlyHostPath(link.target),
+				), nil
+			}
+		}
+	}
+	return false, "", nil
+}
+
+type symlink struct {
+	path   string // host path of the link.
+	target string // resolved host path of the link target.
+	isDir  bool   // tells if the target is a directory.
+}
+
+// stackLinks returns the symbolic links found in the stack directory and its
+// non-stack sub directories. Links to directories are followed, so links
+// inside the target trees are also returned. Each resolved directory is walked
+// only once, which protects against link cycles.
+func (m *Manager) stackLinks(stack *config.Stack) ([]symlink, error) {
+	logger := log.With().
+		Str("action", "stackLinks()").
+		Stringer("stack", stack).
+		Logger()
+
+	var links []symlink
+	visited := map[string]bool{}
+
+	var walk func(dir string, isStackTree bool) error
+	walk = func(dir string, isStackTree bool) error {
+		realdir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return errors.E(err, "resolving directory %q", dir)
+		}
+		if visited[realdir] {
+			return nil
+		}
+		visited[realdir] = true
+
+		return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if p == dir {
+					return nil
+				}
+				if strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				if isStackTree {
+					cfg, found := m.root.Lookup(project.PrjAbsPath(m.root.HostDir(), p))
+					if found && cfg.IsStack() {
+						return filepath.SkipDir
+					}
+				}
+				return nil
+			}
+			if d.Type()&fs.ModeSymlink == 0 {
+				return nil
+			}
+
+			target, err := filepath.EvalSymlinks(p)
+			if err != nil {
+				logger.Debug().
+					Err(err).
+					Str("link", p).
+					Msg("ignoring unresolvable symlink")
+				return nil
+			}
+
+			st, err := os.Stat(target)
+			if err != nil {
+				return errors.E(err, "stat failed on link target %q", target)
+			}
+
+			links = append(links, symlink{
+				path:   p,
+				target: target,
+				isDir:  st.IsDir(),
+			})
+
+			if st.IsDir() {
+				return walk(target, false)
+			}
+			return nil
+		})
+	}
+
+	if err := walk(stack.HostDir(m.root), true); err != nil {
+		return nil, errors.E(err, "walking stack directory")
+	}
+	return links, nil
+}
+
 func (m *Manager) filesApply(dir string, apply func(file fs.DirEntry) error) error {
 	logger := log.With().
 		Str("action", "filesApply()").
@@ -470,6 +845,20 @@ func (m *Manager) filesApply(dir string, apply func(file fs.DirEntry) error) err
 			continue
 		}
 
+		if file.Type()&fs.ModeSymlink != 0 {
+			st, err := os.Stat(filepath.Join(dir, file.Name()))
+			if err != nil {
+				logger.Debug().
+					Err(err).
+					Str("file", file.Name()).
+					Msg("ignoring unresolvable symlink")
+				continue
+			}
+			if st.IsDir() {
+				continue
+			}
+		}
+
 		logger.Debug().
 			Msg("Apply function to file.")
 		err := apply(file)
@@ -484,18 +873,16 @@ func (m *Manager) filesApply(dir string, apply func(file fs.DirEntry) error) err
 // moduleChanged recursively check if the module mod or any of the modules it
 // uses has changed. All .tf files of the module are parsed and this function is
 // called recursively. The visited keep track of the modules already parsed to
-// avoid infinite loops.
+// avoid infinite loops. The changedFiles function returns the list of changed
+// files inside a module directory.
 func (m *Manager) moduleChanged(
 	mod tf.Module, basedir string, visited map[string]bool,
+	changedFiles func(dir string) ([]string, error),
 ) (changed bool, why string, err error) {
 	logger := log.With().
 		Str("action", "moduleChanged()").
 		Logger()
 
-	if _, ok := visited[mod.Source]; ok {
-		return false, "", nil
-	}
-
 	logger.Trace().
 		Str("path", basedir).
 		Msg("Check if module source is local directory.")
@@ -512,30 +899,47 @@ func (m *Manager) moduleChanged(
 
 	logger.Trace().
 		Str("path", modPath).
-		Msg("Get module path info.")
-	st, err := os.Stat(modPath)
+		Msg("Resolve module path symlinks.")
+	realPath, err := filepath.EvalSymlinks(modPath)
+	if err != nil {
+		return false, "", errors.E(err, "\"source\" path %q cannot be resolved", modPath)
+	}
 
-	// TODO(i4k): resolve symlinks
+	// the visited set is keyed by the resolved module path, so the same module
+	// reached through different relative sources or links is checked only once
+	// and link cycles are not followed forever.
+	if _, ok := visited[realPath]; ok {
+		return false, "", nil
+	}
 
+	logger.Trace().
+		Str("path", realPath).
+		Msg("Get module path info.")
+	st, err := os.Stat(realPath)
 	if err != nil || !st.IsDir() {
 		return false, "", errors.E("\"source\" path %q is not a directory", modPath)
 	}
 
+	modDesc := fmt.Sprintf("%q", mod.Source)
+	if realPath != filepath.Clean(modPath) {
+		modDesc = fmt.Sprintf("%q (linked to %q)", mod.Source, m.friendlyHostPath(realPath))
+	}
+
 	logger.Debug().
-		Str("path", modPath).
+		Str("path", realPath).
 		Msg("Get list of changed files.")
-	changedFiles, err := m.listChangedFiles(modPath, m.gitBaseRef)
+	modChangedFiles, err := changedFiles(realPath)
 	if err != nil {
 		return false, "", errors.E(err,
 			"listing changes in the module %q",
 			mod.Source)
 	}
 
-	if len(changedFiles) > 0 {
-		return true, fmt.Sprintf("module %q has unmerged changes", mod.Source), nil
+	if len(modChangedFiles) > 0 {
+		return true, fmt.Sprintf("module %s has unmerged changes", modDesc), nil
 	}
 
-	visited[mod.Source] = true
+	visited[realPath] = true
 
 	logger.Debug().
 		Str("path", modPath).
@@ -565,7 +969,7 @@ func (m *Manager) moduleChanged(
 			logger.Trace().
 				Str("path", modPath).
 				Msg("Get if module is changed.")
-			changed, reason, err = m.moduleChanged(mod2, modPath, visited)
+			changed, reason, err = m.moduleChanged(mod2, modPath, visited, changedFiles)
 			if err != nil {
 				return err
 			}
@@ -586,11 +990,41 @@ func (m *Manager) moduleChanged(
 		return false, "", err
 	}
 
-	return changed, fmt.Sprintf("module %q changed because %s", mod.Source, why), nil
+	return changed, fmt.Sprintf("module %s changed because %s", modDesc, why), nil
+}
+
+// friendlyHostPath returns the project path of the host path if it's inside
+// the project, otherwise the host path itself.
+func (m *Manager) friendlyHostPath(hostpath string) string {
+	rootdir := m.root.HostDir()
+	if realRootdir, err := filepath.EvalSymlinks(rootdir); err == nil {
+		rootdir = realRootdir
+	}
+	if hostpath == rootdir || strings.HasPrefix(hostpath, rootdir+string(filepath.Separator)) {
+		return project.PrjAbsPath(rootdir, hostpath).String()
+	}
+	return hostpath
 }
 
 // listChangedFiles lists all changed files in the dir directory.
-func (m *Manager) listChangedFiles(dir string, gitBaseRef string) ([]string, error) {
+// The result is memoized per dir, as the same modules are usually checked by
+// many stacks.
+func (m *Manager) listChangedFiles(dir string) ([]string, error) {
+	if files, ok := m.changedFiles[dir]; ok {
+		return files, nil
+	}
+	files, err := m.doListChangedFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+	if m.changedFiles == nil {
+		m.changedFiles = map[string][]string{}
+	}
+	m.changedFiles[dir] = files
+	return files, nil
+}
+
+func (m *Manager) doListChangedFiles(dir string) ([]string, error) {
 	logger := log.With().
 		Str("action", "listChangedFiles()").
 		Str("path", dir).
@@ -626,18 +1060,38 @@ func (m *Manager) listChangedFiles(dir string, gitBaseRef string) ([]string, err
 		return nil, err
 	}
 
+	logger.Trace().Msg("Get git root of dir.")
+
+	toplevel, err := g.Root()
+	if err != nil {
+		return nil, errors.E(err, "getting git root of %q", dir)
+	}
+
+	prjToplevel, err := m.projectGitRoot()
+	if err != nil {
+		return nil, err
+	}
+
+	if toplevel != prjToplevel {
+		logger.Trace().
+			Str("submodule", toplevel).
+			Msg("Dir is inside a git submodule.")
+
+		return m.listChangedSubmoduleFiles(g, toplevel, prjToplevel)
+	}
+
 	logger.Trace().Msg("Get commit id of git base ref.")
 
-	baseRef, err := g.RevParse(gitBaseRef)
+	baseRef, err := g.RevParse(m.gitBaseRef)
 	if err != nil {
-		return nil, errors.E(err, "getting revision %q", gitBaseRef)
+		return nil, errors.E(err, "getting revision %q", m.gitBaseRef)
 	}
 
-	logger.Trace().Msg("Get commit id of HEAD.")
+	logger.Trace().Msg("Get commit id of git head ref.")
 
-	headRef, err := g.RevParse("HEAD")
+	headRef, err := g.RevParse(m.gitHeadRef)
 	if err != nil {
-		return nil, errors.E(err, "getting HEAD revision")
+		return nil, errors.E(err, "getting revision %q", m.gitHeadRef)
 	}
 
 	if baseRef == headRef {
@@ -647,6 +1101,126 @@ func (m *Manager) listChangedFiles(dir string, gitBaseRef string) ([]string, err
 	return g.DiffNames(baseRef, headRef)
 }
 
+// expandSubmodules replaces the changed submodules (gitlinks) in the list of
+// changed files by the files changed inside them. A submodule is kept in the
+// list if its changed files are unknown.
+func (m *Manager) expandSubmodules(changedFiles []string) ([]string, error) {
+	var expanded []string
+	for _, file := range changedFiles {
+		abspath := filepath.Join(m.root.HostDir(), file)
+		st, err := os.Stat(abspath)
+		if err != nil || !st.IsDir() {
+			// diff-tree only reports directories for gitlinks.
+			expanded = append(expanded, file)
+			continue
+		}
+
+		subfiles, err := m.listChangedFiles(abspath)
+		if err != nil {
+			return nil, errors.E(err, "listing changes in submodule %q", file)
+		}
+
+		if len(subfiles) == 0 {
+			// eg.: submodule not initialized.
+			expanded = append(expanded, file)
+			continue
+		}
+
+		for _, subfile := range subfiles {
+			if subfile == "." {
+				expanded = append(expanded, file)
+				continue
+			}
+			expanded = append(expanded, path.Join(file, subfile))
+		}
+	}
+	return expanded, nil
+}
+
+// listChangedSubmoduleFiles lists the changed files of a directory inside a git
+// submodule. The changes are computed by comparing the submodule commits
+// (gitlinks) recorded in the project repository at the base and head refs.
+// If the submodule commit changed then the files changed between the two
+// submodule commits are returned, but if they can't be compared (eg.: the
+// commits were not fetched) then the whole directory is considered changed,
+// which is represented by the "." entry.
+func (m *Manager) listChangedSubmoduleFiles(subGit *git.Git, subToplevel, prjToplevel string) ([]string, error) {
+	logger := log.With().
+		Str("action", "listChangedSubmoduleFiles()").
+		Str("submodule", subToplevel).
+		Logger()
+
+	g, err := m.projectGit()
+	if err != nil {
+		return nil, err
+	}
+
+	subpath, err := filepath.Rel(prjToplevel, subToplevel)
+	if err != nil {
+		return nil, errors.E(err, "computing submodule path")
+	}
+	subpath = filepath.ToSlash(subpath)
+
+	logger.Trace().Msg("Get submodule commit at git base ref.")
+
+	// a submodule missing in one of the refs yields an empty commit.
+	baseCommit, _ := g.RevParse(m.gitBaseRef + ":" + subpath)
+
+	logger.Trace().Msg("Get submodule commit at git head ref.")
+
+	headCommit, _ := g.RevParse(m.gitHeadRef + ":" + subpath)
+
+	if baseCommit == headCommit {
+		return []string{}, nil
+	}
+
+	if baseCommit != "" && headCommit != "" {
+		files, err := subGit.DiffNames(baseCommit, headCommit)
+		if err == nil {
+			return files, nil
+		}
+
+		logger.Debug().
+			Err(err).
+			Msg("unable to compare submodule commits, assuming all files changed")
+	}
+
+	return []string{"."}, nil
+}
+
+// projectGit returns a git wrapper for the project root directory.
+func (m *Manager) projectGit() (*git.Git, error) {
+	gOuter, err := m.globalGit()
+	if err != nil {
+		return nil, errors.E(errListChanged, err)
+	}
+
+	return git.WithConfig(git.Config{
+		WorkingDir: m.root.HostDir(),
+		GlobalArgs: setupInheritedGitConfigArgs(gOuter),
+	})
+}
+
+// projectGitRoot returns the root directory of the git repository containing
+// the project.
+func (m *Manager) projectGitRoot() (string, error) {
+	if m.gitToplevel != "" {
+		return m.gitToplevel, nil
+	}
+
+	g, err := m.projectGit()
+	if err != nil {
+		return "", err
+	}
+
+	toplevel, err := g.Root()
+	if err != nil {
+		return "", errors.E(err, "getting git root of project")
+	}
+	m.gitToplevel = toplevel
+	return toplevel, nil
+}
+
 func (m *Manager) globalGit() (*git.Git, error) {
 	var err error
 	if m.outerGit == nil {
@@ -658,6 +1232,21 @@ func (m *Manager) globalGit() (*git.Git, error) {
 	return m.outerGit, err
 }
 
+func (m *Manager) hasChangedGlobalsDataFiles(stack *config.Stack, changedFiles []string) (project.Path, bool) {
+	tree, ok := m.root.Lookup(stack.Dir)
+	if !ok {
+		return project.Path{}, false
+	}
+	for _, dataFile := range globals.DataFiles(tree) {
+		for _, file := range changedFiles {
+			if file == dataFile.String()[1:] { // project paths
+				return dataFile, true
+			}
+		}
+	}
+	return project.Path{}, false
+}
+
 func hasChangedWatchedFiles(stack *config.Stack, changedFiles []string) (project.Path, bool) {
 	for _, watchFile := range stack.Watch {
 		for _, file := range changedFiles {
@@ -0,0 +1,159 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+//go:build linux || darwin
+
+package stack_test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/madlambda/spells/assert"
+	"github.com/terramate-io/terramate/config"
+	"github.com/terramate-io/terramate/project"
+	"github.com/terramate-io/terramate/stack"
+	"github.com/terramate-io/terramate/test"
+)
+
+func TestListChangedStacksFollowsSymlinks(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name        string
+		repobuilder func(t *testing.T) repository
+		want        []string
+		wantReason  string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:        "module linked to a changed directory",
+			repobuilder: linkedModuleChangedRepo,
+			want:        []string{"/stack"},
+			wantReason:  `"/shared/module"`,
+		},
+		{
+			name:        "stack directory linked to a changed directory",
+			repobuilder: linkedDirChangedRepo,
+			want:        []string{"/stack"},
+			wantReason:  `"/shared/module"`,
+		},
+		{
+			name:        "stack file linked to a changed file",
+			repobuilder: linkedFileChangedRepo,
+			want:        []string{"/stack"},
+			wantReason:  `"/shared/vars.tf"`,
+		},
+		{
+			name:        "link cycles are ignored",
+			repobuilder: linkCyclesRepo,
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := tc.repobuilder(t)
+			m := newManager(t, repo.Dir)
+
+			report, err := m.ListChanged()
+			assert.NoError(t, err)
+			assertStacks(t, tc.want, report.Stacks, true)
+
+			for _, entry := range report.Stacks {
+				if !strings.Contains(entry.Reason, tc.wantReason) {
+					t.Fatalf("reason %q does not contain %q", entry.Reason, tc.wantReason)
+				}
+			}
+		})
+	}
+}
+
+func TestListAffectedStacksFollowsSymlinks(t *testing.T) {
+	t.Parallel()
+
+	repo := linkedFileChangedRepo(t)
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+
+	m := stack.NewManager(root, defaultBranch, "")
+	report, err := m.ListAffected([]project.Path{project.NewPath("/shared")})
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/stack"}, report.Stacks, true)
+}
+
+// linkedStackRepo creates a repository with a /shared directory and a /stack
+// stack, with everything merged in main.
+func linkedStackRepo(t *testing.T, setup func(stackdir string)) repository {
+	repo := singleMergeCommitRepoNoStack(t)
+
+	shared := test.Mkdir(t, repo.Dir, "shared")
+	module := test.Mkdir(t, shared, "module")
+	test.WriteFile(t, module, "main.tf", "")
+	test.WriteFile(t, shared, "vars.tf", "")
+
+	stackdir := test.Mkdir(t, repo.Dir, "stack")
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+	createStack(t, root, stackdir)
+
+	setup(stackdir)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	assert.NoError(t, g.Add(repo.Dir), "add files")
+	assert.NoError(t, g.Commit("files"), "commit files")
+	assert.NoError(t, g.Push("origin", "main"), "push origin main")
+	return repo
+}
+
+func changeFile(t *testing.T, repo repository, relpath string) {
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	assert.NoError(t, g.Checkout("testbranch", true))
+	file := test.WriteFile(t, repo.Dir, relpath, "# changed")
+	assert.NoError(t, g.Add(file))
+	assert.NoError(t, g.Commit("change "+relpath))
+}
+
+func linkedModuleChangedRepo(t *testing.T) repository {
+	repo := linkedStackRepo(t, func(stackdir string) {
+		modules := test.Mkdir(t, filepath.Dir(stackdir), "modules")
+		test.Symlink(t, "../shared/module", filepath.Join(modules, "linked"))
+		test.WriteFile(t, stackdir, "main.tf", `
+module "linked" {
+	source = "../modules/linked"
+}
+`)
+	})
+	changeFile(t, repo, "shared/module/main.tf")
+	return repo
+}
+
+func linkedDirChangedRepo(t *testing.T) repository {
+	repo := linkedStackRepo(t, func(stackdir string) {
+		test.Symlink(t, "../shared/module", filepath.Join(stackdir, "module"))
+	})
+	changeFile(t, repo, "shared/module/main.tf")
+	return repo
+}
+
+func linkedFileChangedRepo(t *testing.T) repository {
+	repo := linkedStackRepo(t, func(stackdir string) {
+		test.Symlink(t, "../shared/vars.tf", filepath.Join(stackdir, "vars.tf"))
+	})
+	changeFile(t, repo, "shared/vars.tf")
+	return repo
+}
+
+func linkCyclesRepo(t *testing.T) repository {
+	repo := linkedStackRepo(t, func(stackdir string) {
+		test.Symlink(t, ".", filepath.Join(stackdir, "self"))
+		test.Symlink(t, "b", filepath.Join(stackdir, "a"))
+		test.Symlink(t, "a", filepath.Join(stackdir, "b"))
+		test.Symlink(t, "../shared", filepath.Join(stackdir, "shared"))
+		test.Symlink(t, "../stack", filepath.Join(filepath.Dir(stackdir), "shared", "back"))
+	})
+	changeFile(t, repo, "unrelated.txt")
+	return repo
+}
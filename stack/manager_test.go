@@ -5,6 +5,7 @@ package stack_test
 
 import (
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"testing"
@@ -24,12 +25,14 @@ type repository struct {
 type listTestResult struct {
 	list    []string
 	changed []string
+	missing []string
 	err     error
 }
 
 type listTestcase struct {
 	name        string
 	baseRef     string
+	headRef     string
 	repobuilder func(t *testing.T) repository
 	want        listTestResult
 }
@@ -93,6 +96,42 @@ func TestListChangedStacks(t *testing.T) {
 				changed: []string{"/changed-stack"},
 			},
 		},
+		{
+			name:        "multiple stacks: one changed in head ref not checked out",
+			repobuilder: multipleStacksOneChangedRepoOnMain,
+			headRef:     "testbranch2",
+			want: listTestResult{
+				list:    []string{"/not-changed-stack"},
+				missing: []string{"/changed-stack"},
+			},
+		},
+		{
+			name:        "multiple stacks: stack changed in head ref not checked out",
+			repobuilder: multipleStacksOneChangedInHeadRepo,
+			headRef:     "testbranch2",
+			want: listTestResult{
+				list:    []string{"/not-changed-stack"},
+				changed: []string{"/not-changed-stack"},
+				missing: []string{"/changed-stack"},
+			},
+		},
+		{
+			name:        "multiple stacks: child stack created in head ref not checked out",
+			repobuilder: multipleStacksChildStackInHeadRepo,
+			headRef:     "testbranch2",
+			want: listTestResult{
+				list:    []string{"/not-changed-stack"},
+				missing: []string{"/changed-stack", "/not-changed-stack/child"},
+			},
+		},
+		{
+			name:        "multiple stacks: no changes when head ref equals base",
+			repobuilder: multipleStacksOneChangedRepo,
+			headRef:     defaultBranch,
+			want: listTestResult{
+				list: []string{"/changed-stack", "/not-changed-stack"},
+			},
+		},
 		{
 			name:        "multiple stacks: multiple changed",
 			repobuilder: multipleChangedStacksRepo,
@@ -147,7 +186,7 @@ func TestListChangedStacks(t *testing.T) {
 			repo := tc.repobuilder(t)
 			root, err := config.LoadRoot(repo.Dir)
 			assert.NoError(t, err)
-			m := stack.NewManager(root, tc.baseRef)
+			m := stack.NewManager(root, tc.baseRef, tc.headRef)
 
 			report, err := m.ListChanged()
 			assert.EqualErrs(t, tc.want.err, err, "ListChanged() error")
@@ -155,6 +194,16 @@ func TestListChangedStacks(t *testing.T) {
 			changedStacks := report.Stacks
 			assertStacks(t, tc.want.changed, changedStacks, true)
 
+			missing := []string{}
+			for _, dir := range report.Missing {
+				missing = append(missing, dir.String())
+			}
+			assert.EqualInts(t, len(tc.want.missing), len(missing),
+				"wrong number of missing stacks: %v", missing)
+			for i, dir := range tc.want.missing {
+				assert.EqualStrings(t, dir, missing[i], "missing stack mismatch")
+			}
+
 			report, err = m.List()
 			assert.EqualErrs(t, tc.want.err, err, "List() error")
 
@@ -164,6 +213,29 @@ func TestListChangedStacks(t *testing.T) {
 	}
 }
 
+func TestListChangedHeadRefKeepsWorkingTree(t *testing.T) {
+	t.Parallel()
+	repo := multipleStacksOneChangedInHeadRepo(t)
+
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+	m := stack.NewManager(root, defaultBranch, "testbranch2")
+
+	report, err := m.ListChanged()
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/not-changed-stack"}, report.Stacks, true)
+	assert.EqualStrings(t, "stack has unmerged changes", report.Stacks[0].Reason)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	branch, err := g.CurrentBranch()
+	assert.NoError(t, err)
+	assert.EqualStrings(t, "main", branch)
+
+	// the missing stack is not checked out.
+	_, err = os.Stat(filepath.Join(repo.Dir, "changed-stack"))
+	assert.IsTrue(t, os.IsNotExist(err))
+}
+
 func TestListChangedStackReason(t *testing.T) {
 	t.Parallel()
 	repo := singleNotMergedCommitBranch(t)
@@ -393,6 +465,48 @@ func multipleStacksOneChangedRepo(t *testing.T) repository {
 	return repo
 }
 
+// multipleStacksOneChangedRepoOnMain is like multipleStacksOneChangedRepo but
+// the main branch is checked out, so the changed stack only exists in the
+// testbranch2 branch.
+func multipleStacksOneChangedRepoOnMain(t *testing.T) repository {
+	repo := multipleStacksOneChangedRepo(t)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	assert.NoError(t, g.Checkout("main", false), "checkout main failed")
+	return repo
+}
+
+// multipleStacksOneChangedInHeadRepo is like multipleStacksOneChangedRepoOnMain
+// but the not-changed-stack, which exists in the main branch, is also changed
+// in the testbranch2 branch.
+func multipleStacksOneChangedInHeadRepo(t *testing.T) repository {
+	repo := multipleStacksOneChangedRepo(t)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	test.WriteFile(t, repo.Dir, "not-changed-stack/main.tf", "# changed")
+	assert.NoError(t, g.Add(repo.Dir), "git add failed")
+	assert.NoError(t, g.Commit("change stack"), "commit failed")
+
+	assert.NoError(t, g.Checkout("main", false), "checkout main failed")
+	return repo
+}
+
+// multipleStacksChildStackInHeadRepo is like multipleStacksOneChangedRepoOnMain
+// but the testbranch2 branch also creates a child stack of the
+// not-changed-stack, whose files must not change its parent stack.
+func multipleStacksChildStackInHeadRepo(t *testing.T) repository {
+	repo := multipleStacksOneChangedRepo(t)
+
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	test.WriteFile(t, repo.Dir, "not-changed-stack/child/stack.tm", "stack {\n}\n")
+	test.WriteFile(t, repo.Dir, "not-changed-stack/child/main.tf", "# child")
+	assert.NoError(t, g.Add(repo.Dir), "git add failed")
+	assert.NoError(t, g.Commit("child stack"), "commit failed")
+
+	assert.NoError(t, g.Checkout("main", false), "checkout main failed")
+	return repo
+}
+
 func multipleChangedStacksRepo(t *testing.T) repository {
 	repo := multipleStacksOneChangedRepo(t)
 
@@ -551,10 +665,214 @@ module "module2" {
 func newManager(t *testing.T, basedir string) *stack.Manager {
 	root, err := config.LoadRoot(basedir)
 	assert.NoError(t, err)
-	return stack.NewManager(root, defaultBranch)
+	return stack.NewManager(root, defaultBranch, "")
 }
 
 func createStack(t *testing.T, root *config.Root, absdir string) {
 	dir := project.PrjAbsPath(root.HostDir(), absdir)
 	assert.NoError(t, stack.Create(root, config.Stack{Dir: dir}), "terramate init failed")
 }
+
+func TestListChangedStacksWithSubmodules(t *testing.T) {
+	t.Parallel()
+
+	type testcase struct {
+		name       string
+		changed    string
+		want       []string
+		wantReason string
+	}
+
+	for _, tc := range []testcase{
+		{
+			name:       "file changed in module used by stack",
+			changed:    "mod1/main.tf",
+			want:       []string{"/modules/sub/stack", "/stack"},
+			wantReason: "mod1",
+		},
+		{
+			name:    "file changed in module not used by stack",
+			changed: "mod2/main.tf",
+		},
+		{
+			name:       "file changed in stack inside submodule",
+			changed:    "stack/main.tf",
+			want:       []string{"/modules/sub/stack"},
+			wantReason: "unmerged changes",
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := submoduleRepo(t)
+
+			g := test.NewGitWrapper(t, repo.Dir, []string{})
+			assert.NoError(t, g.Checkout("testbranch", true))
+
+			subdir := filepath.Join(repo.Dir, "modules", "sub")
+			subgit := test.NewGitWrapper(t, subdir, []string{})
+			_, err := subgit.Exec("config", "user.name", test.Username)
+			assert.NoError(t, err)
+			_, err = subgit.Exec("config", "user.email", test.Email)
+			assert.NoError(t, err)
+
+			test.WriteFile(t, subdir, tc.changed, "# changed")
+			assert.NoError(t, subgit.Add(tc.changed))
+			assert.NoError(t, subgit.Commit("change "+tc.changed))
+
+			assert.NoError(t, g.Add(subdir))
+			assert.NoError(t, g.Commit("bump submodule"))
+
+			m := newManager(t, repo.Dir)
+			report, err := m.ListChanged()
+			assert.NoError(t, err)
+			assertStacks(t, tc.want, report.Stacks, true)
+
+			for _, entry := range report.Stacks {
+				if !strings.Contains(entry.Reason, tc.wantReason) {
+					t.Fatalf("reason %q does not contain %q", entry.Reason, tc.wantReason)
+				}
+			}
+		})
+	}
+}
+
+func TestListChangedSubmoduleGitlinkInsideStack(t *testing.T) {
+	t.Parallel()
+
+	subdir := test.NewRepo(t)
+	subgit := test.NewGitWrapper(t, subdir, []string{})
+	test.WriteFile(t, subdir, "stack/stack.tm.hcl", "stack {}")
+	assert.NoError(t, subgit.Add(subdir))
+	assert.NoError(t, subgit.Commit("add stack"))
+
+	repo := singleMergeCommitRepoNoStack(t)
+	g := test.NewGitWrapper(t, repo.Dir, nil)
+
+	appdir := test.Mkdir(t, repo.Dir, "app")
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+	createStack(t, root, appdir)
+
+	_, err = g.AddSubmodule("app/sub", subdir)
+	assert.NoError(t, err)
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("add submodule"))
+	assert.NoError(t, g.Push("origin", "main"))
+
+	assert.NoError(t, g.Checkout("testbranch", true))
+
+	// the gitlink points to a commit missing in the submodule, then its
+	// changes can't be computed and the whole submodule is changed.
+	_, err = g.Exec("update-index", "--cacheinfo",
+		"160000,1111111111111111111111111111111111111111,app/sub")
+	assert.NoError(t, err)
+	assert.NoError(t, g.Commit("bump submodule"))
+
+	m := newManager(t, repo.Dir)
+	report, err := m.ListChanged()
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/app/sub/stack"}, report.Stacks, true)
+	assert.IsTrue(t, strings.Contains(report.Stacks[0].Reason, "changed submodule"),
+		"unexpected reason: %s", report.Stacks[0].Reason)
+}
+
+// submoduleRepo creates a repository with the /modules/sub git submodule,
+// containing the mod1 and mod2 modules and a stack using mod1. The /stack stack
+// of the repository also uses the submodule's mod1 module.
+func submoduleRepo(t *testing.T) repository {
+	subdir := test.NewRepo(t)
+	subgit := test.NewGitWrapper(t, subdir, []string{})
+	test.WriteFile(t, subdir, "mod1/main.tf", "")
+	test.WriteFile(t, subdir, "mod2/main.tf", "")
+	test.WriteFile(t, subdir, "stack/stack.tm.hcl", "stack {}")
+	test.WriteFile(t, subdir, "stack/main.tf", `
+module "mod1" {
+	source = "../mod1"
+}
+`)
+	assert.NoError(t, subgit.Add(subdir))
+	assert.NoError(t, subgit.Commit("add modules"))
+
+	repo := singleMergeCommitRepoNoStack(t)
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+
+	test.Mkdir(t, repo.Dir, "modules")
+	_, err := g.AddSubmodule("modules/sub", subdir)
+	assert.NoError(t, err)
+
+	stackdir := test.Mkdir(t, repo.Dir, "stack")
+	root, err := config.LoadRoot(repo.Dir)
+	assert.NoError(t, err)
+	createStack(t, root, stackdir)
+
+	test.WriteFile(t, stackdir, "main.tf", `
+module "mod1" {
+	source = "../modules/sub/mod1"
+}
+`)
+
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("add submodule"))
+	assert.NoError(t, g.Push("origin", "main"))
+	return repo
+}
+
+func TestListChangedStackMatrix(t *testing.T) {
+	t.Parallel()
+
+	repo := singleMergeCommitRepoNoStack(t)
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+	assert.NoError(t, g.Checkout("testbranch", true))
+
+	test.WriteFile(t, repo.Dir, "infra/stack.tm.hcl", `
+stack {
+  matrix {
+    env = ["dev", "prd"]
+  }
+}
+`)
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("add stack matrix"))
+
+	m := newManager(t, repo.Dir)
+	report, err := m.ListChanged()
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/infra/dev", "/infra/prd"}, report.Stacks, true)
+	for _, entry := range report.Stacks {
+		assert.EqualStrings(t, "stack matrix definition has unmerged changes", entry.Reason)
+	}
+}
+
+func TestListChangedStacksGlobalsDataFile(t *testing.T) {
+	t.Parallel()
+
+	repo := singleMergeCommitRepoNoStack(t)
+	g := test.NewGitWrapper(t, repo.Dir, []string{})
+
+	test.WriteFile(t, repo.Dir, "data/common.yaml", "region: us-east-1\n")
+	test.WriteFile(t, repo.Dir, "infra/globals.tm.hcl", `
+globals_file {
+  path = "/data/common.yaml"
+}
+`)
+	test.WriteFile(t, repo.Dir, "infra/stack/stack.tm.hcl", "stack {}\n")
+	test.WriteFile(t, repo.Dir, "other/stack.tm.hcl", "stack {}\n")
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("add stacks"))
+	assert.NoError(t, g.Push("origin", "main"))
+
+	assert.NoError(t, g.Checkout("testbranch", true))
+	test.WriteFile(t, repo.Dir, "data/common.yaml", "region: us-west-1\n")
+	assert.NoError(t, g.Add(repo.Dir))
+	assert.NoError(t, g.Commit("change data file"))
+
+	m := newManager(t, repo.Dir)
+	report, err := m.ListChanged()
+	assert.NoError(t, err)
+	assertStacks(t, []string{"/infra/stack"}, report.Stacks, true)
+	assert.EqualStrings(t,
+		`stack changed because globals data file "/data/common.yaml" changed`,
+		report.Stacks[0].Reason)
+}
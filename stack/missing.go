@@ -0,0 +1,111 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stack
+
+import (
+	"path/filepath"
+	"sort"
+
+	hhcl "github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/json"
+	"github.com/rs/zerolog/log"
+	"github.com/terramate-io/terramate/errors"
+	"github.com/terramate-io/terramate/fs"
+	"github.com/terramate-io/terramate/git"
+	"github.com/terramate-io/terramate/project"
+)
+
+// listMissingStacks returns the stacks changed in the head revision which
+// don't exist in the working tree. The changed Terramate files are read from
+// the git objects of the head revision, so it's never checked out.
+func (m *Manager) listMissingStacks(g *git.Git, headCommit string) ([]project.Path, error) {
+	logger := log.With().
+		Str("action", "listMissingStacks()").
+		Str("head", headCommit).
+		Logger()
+
+	changedFiles, err := m.listChangedFiles(m.root.HostDir())
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	for _, path := range changedFiles {
+		if !fs.IsTerramateFile(filepath.Base(path)) {
+			continue
+		}
+		dir := project.PrjAbsPath(m.root.HostDir(), filepath.Join(m.root.HostDir(), filepath.Dir(path)))
+		if tree, found := m.root.Lookup(dir); found && tree.IsStack() {
+			continue
+		}
+		candidates = append(candidates, path)
+	}
+
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// deleted files are also listed as changed.
+	headFiles, err := g.LsTreeFiles(headCommit, candidates...)
+	if err != nil {
+		return nil, errors.E(err, "listing files of revision %q", headCommit)
+	}
+
+	stackSchema := &hhcl.BodySchema{
+		Blocks: []hhcl.BlockHeaderSchema{
+			{Type: "stack"},
+		},
+	}
+
+	found := map[project.Path]struct{}{}
+	for _, path := range headFiles {
+		content, err := g.ShowFile(headCommit, path)
+		if err != nil {
+			return nil, errors.E(err, "reading file %q of revision %q", path, headCommit)
+		}
+
+		var (
+			file  *hhcl.File
+			diags hhcl.Diagnostics
+		)
+		if fs.IsTerramateJSONFile(path) {
+			file, diags = json.Parse([]byte(content), path)
+		} else {
+			file, diags = hclsyntax.ParseConfig([]byte(content), path, hhcl.InitialPos)
+		}
+		if diags.HasErrors() {
+			logger.Warn().
+				Str("file", path).
+				Err(diags).
+				Msg("ignoring invalid configuration file of head revision")
+			continue
+		}
+
+		body, _, _ := file.Body.PartialContent(stackSchema)
+		if len(body.Blocks) > 0 {
+			dir := filepath.Join(m.root.HostDir(), filepath.Dir(path))
+			found[project.PrjAbsPath(m.root.HostDir(), dir)] = struct{}{}
+		}
+	}
+
+	missing := make([]project.Path, 0, len(found))
+	for dir := range found {
+		missing = append(missing, dir)
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].String() < missing[j].String()
+	})
+	return missing, nil
+}
+
+// isInsideStacks tells if the path is inside any of the stack dirs.
+func isInsideStacks(path project.Path, stackdirs []project.Path) bool {
+	for _, dir := range stackdirs {
+		if dir.String() == "/" || path == dir || path.HasPrefix(dir.String()+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -27,6 +27,23 @@ import (
 
 var regexCache map[string]*regexp.Regexp
 
+// baseDirFuncs are the functions resolving relative paths from the base
+// directory given to [Functions].
+var baseDirFuncs = map[string]struct{}{
+	"tm_abspath":          {},
+	"tm_file":             {},
+	"tm_fileexists":       {},
+	"tm_fileset":          {},
+	"tm_filebase64":       {},
+	"tm_filebase64sha256": {},
+	"tm_filebase64sha512": {},
+	"tm_filemd5":          {},
+	"tm_filesha1":         {},
+	"tm_filesha256":       {},
+	"tm_filesha512":       {},
+	"tm_templatefile":     {},
+}
+
 func init() {
 	regexCache = map[string]*regexp.Regexp{}
 }
@@ -49,7 +66,7 @@ func Functions(basedir string) map[string]function.Function {
 	scope := &tflang.Scope{BaseDir: basedir}
 	tffuncs := scope.Functions()
 
-	// not supported functions
+	// replaced by Terramate sensitive values
 	delete(tffuncs, "sensitive")
 	delete(tffuncs, "nonsensitive")
 
@@ -68,6 +85,9 @@ func Functions(basedir string) map[string]function.Function {
 	tmfuncs["tm_ternary"] = TernaryFunc()
 
 	tmfuncs["tm_version_match"] = VersionMatch()
+
+	tmfuncs["tm_sensitive"] = SensitiveFunc()
+	tmfuncs["tm_nonsensitive"] = NonsensitiveFunc()
 	return tmfuncs
 }
 
@@ -75,26 +95,20 @@ func Functions(basedir string) map[string]function.Function {
 // functions.
 func NoFS(basedir string) map[string]function.Function {
 	funcs := Functions(basedir)
-	fsFuncNames := []string{
-		"tm_abspath",
-		"tm_file",
-		"tm_fileexists",
-		"tm_fileset",
-		"tm_filebase64",
-		"tm_filebase64sha256",
-		"tm_filebase64sha512",
-		"tm_filemd5",
-		"tm_filesha1",
-		"tm_filesha256",
-		"tm_filesha512",
-		"tm_templatefile",
-	}
-	for _, name := range fsFuncNames {
+	for name := range baseDirFuncs {
 		delete(funcs, name)
 	}
 	return funcs
 }
 
+// IsBaseDirFunc tells if the function depends on the base directory given to
+// [Functions], ie. it resolves relative paths from it. These are the
+// functions excluded by [NoFS].
+func IsBaseDirFunc(name string) bool {
+	_, ok := baseDirFuncs[name]
+	return ok
+}
+
 // Regex is a copy of Terraform [stdlib.RegexFunc] but with cached compiled
 // patterns.
 func Regex() function.Function {
@@ -333,3 +333,24 @@ func TestStdlibNewFunctionsFailIfBasedirIsNotADirectory(t *testing.T) {
 func init() {
 	zerolog.SetGlobalLevel(zerolog.Disabled)
 }
+
+func TestStdlibNoFSExcludesBaseDirFunctions(t *testing.T) {
+	t.Parallel()
+
+	basedir := test.TempDir(t)
+	all := stdlib.Functions(basedir)
+	nofs := stdlib.NoFS(basedir)
+	for name := range all {
+		_, inNoFS := nofs[name]
+		if stdlib.IsBaseDirFunc(name) == inNoFS {
+			t.Errorf("function %s: base dir function = %t but in NoFS = %t",
+				name, stdlib.IsBaseDirFunc(name), inNoFS)
+		}
+	}
+	for _, name := range []string{"tm_abspath", "tm_file", "tm_fileset", "tm_templatefile"} {
+		if _, ok := all[name]; !ok {
+			t.Errorf("function %s not found", name)
+		}
+		assert.IsTrue(t, stdlib.IsBaseDirFunc(name), "function %s", name)
+	}
+}
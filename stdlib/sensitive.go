@@ -0,0 +1,59 @@
+// Copyright 2023 Terramate GmbH
+// SPDX-License-Identifier: MPL-2.0
+
+package stdlib
+
+import (
+	"github.com/terramate-io/terramate/hcl/eval"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/function"
+)
+
+// SensitiveFunc is the `tm_sensitive` function implementation.
+// The `tm_sensitive(value)` returns the value marked as sensitive, then it's
+// redacted by the CLI outputs but used as is by code generation and commands.
+func SensitiveFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name:             "value",
+				Type:             cty.DynamicPseudoType,
+				AllowUnknown:     true,
+				AllowNull:        true,
+				AllowMarked:      true,
+				AllowDynamicType: true,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			return args[0].Mark(eval.SensitiveMark), nil
+		},
+	})
+}
+
+// NonsensitiveFunc is the `tm_nonsensitive` function implementation.
+// The `tm_nonsensitive(value)` returns the value without the sensitive marks,
+// exposing it in the CLI outputs.
+func NonsensitiveFunc() function.Function {
+	return function.New(&function.Spec{
+		Params: []function.Parameter{
+			{
+				Name:             "value",
+				Type:             cty.DynamicPseudoType,
+				AllowUnknown:     true,
+				AllowNull:        true,
+				AllowMarked:      true,
+				AllowDynamicType: true,
+			},
+		},
+		Type: func(args []cty.Value) (cty.Type, error) {
+			return args[0].Type(), nil
+		},
+		Impl: func(args []cty.Value, retType cty.Type) (cty.Value, error) {
+			val, _ := args[0].UnmarkDeep()
+			return val, nil
+		},
+	})
+}
@@ -240,6 +240,7 @@ func assertTerramateConfigBlock(t *testing.T, got, want *hcl.RootConfig) {
 
 	assertTerramateRunBlock(t, got.Run, want.Run)
 	assertTerramateCloudBlock(t, got.Cloud, want.Cloud)
+	assertTerramateStacksBlock(t, got.Stacks, want.Stacks)
 }
 
 func assertGenHCLBlocks(t *testing.T, got, want []hcl.GenHCLBlock) {
@@ -324,6 +325,31 @@ func assertTerramateCloudBlock(t *testing.T, got, want *hcl.CloudConfig) {
 	}
 }
 
+func assertTerramateStacksBlock(t *testing.T, got, want *hcl.StacksConfig) {
+	t.Helper()
+
+	if (want == nil) != (got == nil) {
+		t.Fatalf("want.Stacks[%+v] != got.Stacks[%+v]", want, got)
+	}
+
+	if want == nil {
+		return
+	}
+
+	AssertDiff(t, got.AllowedTags, want.AllowedTags, "allowed_tags mismatch")
+	AssertDiff(t, got.RequiredTags, want.RequiredTags, "required_tags mismatch")
+	assert.IsTrue(t, (want.NamePattern == nil) == (got.NamePattern == nil),
+		"want.Stacks.NamePattern %v != got.Stacks.NamePattern %v",
+		want.NamePattern, got.NamePattern)
+	if want.NamePattern != nil {
+		assert.EqualStrings(t, want.NamePattern.String(), got.NamePattern.String(),
+			"name_pattern mismatch")
+	}
+	assert.IsTrue(t, want.RequireID == got.RequireID,
+		"want.Stacks.RequireID %v != got.Stacks.RequireID %v",
+		want.RequireID, got.RequireID)
+}
+
 // hclFromAttributes ensures that we always build the same HCL document
 // given an hcl.Attributes.
 func hclFromAttributes(t *testing.T, attrs ast.Attributes) string {
@@ -121,6 +121,11 @@ func Globals(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("globals", builders...)
 }
 
+// Function is a helper for a "function" block.
+func Function(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
+	return Block("function", builders...)
+}
+
 // Map is a helper for a "map" block.
 func Map(builders ...hclwrite.BlockBuilder) *hclwrite.Block {
 	return Block("map", builders...)